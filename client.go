@@ -8,7 +8,9 @@ package lsp
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"strings"
 
 	"go.bug.st/json"
 	"go.bug.st/lsp/jsonrpc"
@@ -39,6 +41,25 @@ type ServerMessagesHandler interface {
 	TextDocumentPublishDiagnostics(jsonrpc.FunctionLogger, *PublishDiagnosticsParams)
 }
 
+// The following interfaces are optional: the Client dispatches the request to
+// the handler only if it implements the corresponding interface, otherwise a
+// MethodNotFound error is returned to the server.
+
+// InlineValueRefreshHandler is implemented by the handlers that support the workspace/inlineValue/refresh request.
+type InlineValueRefreshHandler interface {
+	WorkspaceInlineValueRefresh(context.Context, jsonrpc.FunctionLogger) *jsonrpc.ResponseError
+}
+
+// InlayHintRefreshHandler is implemented by the handlers that support the workspace/inlayHint/refresh request.
+type InlayHintRefreshHandler interface {
+	WorkspaceInlayHintRefresh(context.Context, jsonrpc.FunctionLogger) *jsonrpc.ResponseError
+}
+
+// DiagnosticRefreshHandler is implemented by the handlers that support the workspace/diagnostic/refresh request.
+type DiagnosticRefreshHandler interface {
+	WorkspaceDiagnosticRefresh(context.Context, jsonrpc.FunctionLogger) *jsonrpc.ResponseError
+}
+
 // Client is an LSP Client
 type Client struct {
 	conn               *jsonrpc.Connection
@@ -46,20 +67,28 @@ type Client struct {
 	customNotification map[string]CustomNotification
 	customRequest      map[string]CustomRequest
 	errorHandler       func(e error)
+	lifecycle          lifecycle
 }
 
-func NewClient(in io.Reader, out io.Writer, handler ServerMessagesHandler) *Client {
+func NewClient(in io.Reader, out io.Writer, handler ServerMessagesHandler, opts ...ConnectionOption) *Client {
+	options := newConnectionOptions(opts)
+	return NewClientWithFramer(options.framer(in, out), handler)
+}
+
+// NewClientWithFramer creates a Client that exchanges the messages through
+// the given Framer.
+func NewClientWithFramer(framer jsonrpc.Framer, handler ServerMessagesHandler) *Client {
 	client := &Client{
 		errorHandler:       func(e error) {},
 		customNotification: map[string]CustomNotification{},
 		customRequest:      map[string]CustomRequest{},
 	}
 	client.handler = handler
-	client.conn = jsonrpc.NewConnection(
-		in, out,
+	client.conn = jsonrpc.NewConnectionWithFramer(
+		framer,
 		client.requestDispatcher,
 		client.notificationDispatcher,
-		client.errorHandler)
+		func(e error) { client.errorHandler(e) })
 	return client
 }
 
@@ -67,10 +96,63 @@ func (client *Client) SetLogger(l jsonrpc.Logger) {
 	client.conn.SetLogger(l)
 }
 
+// SetRequestsConcurrency sets how many incoming requests may be handled
+// concurrently, see jsonrpc.Connection.SetRequestsConcurrency for details.
+// A concurrent mode is required if a handler needs to send a request
+// to the other side and wait for the response.
+func (client *Client) SetRequestsConcurrency(n int) {
+	client.conn.SetRequestsConcurrency(n)
+}
+
 func (client *Client) SetErrorHandler(handler func(e error)) {
 	client.errorHandler = handler
 }
 
+// SetLifecycleGuard enables or disables the enforcement of the lifecycle
+// rules of the specification on the outgoing messages: the requests sent
+// before the initialize result or after shutdown, and the notifications sent
+// before initialized or after shutdown (except exit), fail with a
+// LifecycleError without being sent.
+// The guard is disabled by default.
+func (client *Client) SetLifecycleGuard(enabled bool) {
+	client.lifecycle.setGuard(enabled)
+}
+
+// LifecycleState returns the current lifecycle state of the session.
+func (client *Client) LifecycleState() LifecycleState {
+	return client.lifecycle.current()
+}
+
+func (client *Client) sendRequest(ctx context.Context, method string, params any) (json.RawMessage, *jsonrpc.ResponseError, error) {
+	raw, err := encodeParams(method, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := client.lifecycle.request(method); err != nil {
+		return nil, nil, err
+	}
+	resp, respErr, err := client.conn.SendRequest(ctx, method, raw)
+	if method == "initialize" {
+		client.lifecycle.initializeResult(err == nil && respErr == nil)
+	}
+	return resp, respErr, err
+}
+
+func (client *Client) reportError(err error) {
+	client.errorHandler(err)
+}
+
+func (client *Client) sendNotification(method string, params any) error {
+	raw, err := encodeParams(method, params)
+	if err != nil {
+		return err
+	}
+	if err := client.lifecycle.notification(method); err != nil {
+		return err
+	}
+	return client.conn.SendNotification(method, raw)
+}
+
 func (client *Client) RegisterCustomNotification(method string, callback CustomNotification) {
 	client.customNotification[method] = callback
 }
@@ -83,6 +165,23 @@ func (client *Client) Run() {
 	client.conn.Run()
 }
 
+// RunWithContext is like Run but closes the connection when the given
+// context is done.
+func (client *Client) RunWithContext(ctx context.Context) {
+	client.conn.RunWithContext(ctx)
+}
+
+// WaitPendingResponses waits until all the incoming requests have been
+// answered, or until the given context is done.
+func (client *Client) WaitPendingResponses(ctx context.Context) error {
+	return client.conn.WaitPendingResponses(ctx)
+}
+
+// Close closes the connection, see jsonrpc.Connection.Close for details.
+func (client *Client) Close() {
+	client.conn.Close()
+}
+
 func (client *Client) notificationDispatcher(logger jsonrpc.FunctionLogger, method string, req json.RawMessage) {
 	switch method {
 	case "$/progress":
@@ -128,49 +227,51 @@ func (client *Client) notificationDispatcher(logger jsonrpc.FunctionLogger, meth
 	default:
 		if handler, ok := client.customNotification[method]; ok {
 			handler(logger, req)
-		} else {
-			panic("unimplemented notification: " + method)
+		} else if !strings.HasPrefix(method, "$/") {
+			// Notifications starting with "$/" are protocol implementation
+			// dependent and may be safely ignored.
+			client.errorHandler(fmt.Errorf("unimplemented notification: %s", method))
 		}
 	}
 }
 
 func (client *Client) requestDispatcher(ctx context.Context, logger jsonrpc.FunctionLogger, method string, req json.RawMessage, respCallback func(json.RawMessage, *jsonrpc.ResponseError)) {
 	resp := func(res interface{}, err *jsonrpc.ResponseError) {
-		respCallback(EncodeMessage(res), err)
+		respCallback(encodeResult(method, res, err, client.errorHandler))
 	}
 	switch method {
 	case "window/showMessageRequest":
 		var param ShowMessageRequestParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			client.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
 		resp(client.handler.WindowShowMessageRequest(ctx, logger, &param))
 	case "window/showDocument":
 		var param ShowDocumentParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			client.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
 		resp(client.handler.WindowShowDocument(ctx, logger, &param))
 	case "window/workDoneProgress/create":
 		var param WorkDoneProgressCreateParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			client.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
 		resp(nil, client.handler.WindowWorkDoneProgressCreate(ctx, logger, &param))
 	case "client/registerCapability":
 		var param RegistrationParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			client.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
 		resp(nil, client.handler.ClientRegisterCapability(ctx, logger, &param))
 	case "client/unregisterCapability":
 		var param UnregistrationParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			client.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
 		resp(nil, client.handler.ClientUnregisterCapability(ctx, logger, &param))
@@ -179,24 +280,45 @@ func (client *Client) requestDispatcher(ctx context.Context, logger jsonrpc.Func
 	case "workspace/configuration":
 		var param ConfigurationParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			client.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
 		resp(client.handler.WorkspaceConfiguration(ctx, logger, &param))
 	case "workspace/applyEdit":
 		var param ApplyWorkspaceEditParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			client.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
 		resp(client.handler.WorkspaceApplyEdit(ctx, logger, &param))
 	case "workspace/codeLens/refresh":
 		resp(nil, client.handler.WorkspaceCodeLensRefresh(ctx, logger))
+	case "workspace/inlineValue/refresh":
+		h, ok := client.handler.(InlineValueRefreshHandler)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
+		resp(nil, h.WorkspaceInlineValueRefresh(ctx, logger))
+	case "workspace/inlayHint/refresh":
+		h, ok := client.handler.(InlayHintRefreshHandler)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
+		resp(nil, h.WorkspaceInlayHintRefresh(ctx, logger))
+	case "workspace/diagnostic/refresh":
+		h, ok := client.handler.(DiagnosticRefreshHandler)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
+		resp(nil, h.WorkspaceDiagnosticRefresh(ctx, logger))
 	default:
 		if handler, ok := client.customRequest[method]; ok {
 			resp(handler(ctx, logger, req))
 		} else {
-			panic("unimplemented request: " + method)
+			respCallback(nil, methodNotFoundError(method))
 		}
 	}
 }
@@ -204,7 +326,7 @@ func (client *Client) requestDispatcher(ctx context.Context, logger jsonrpc.Func
 // Requests to Server
 
 func (client *Client) Initialize(ctx context.Context, param *InitializeParams) (*InitializeResult, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "initialize", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "initialize", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -213,12 +335,12 @@ func (client *Client) Initialize(ctx context.Context, param *InitializeParams) (
 }
 
 func (client *Client) Shutdown(ctx context.Context) (*jsonrpc.ResponseError, error) {
-	_, respErr, err := client.conn.SendRequest(ctx, "shutdown", EncodeMessage(jsonrpc.NullResult))
+	_, respErr, err := client.sendRequest(ctx, "shutdown", jsonrpc.NullResult)
 	return respErr, err
 }
 
 func (client *Client) WorkspaceSymbol(ctx context.Context, param *WorkspaceSymbolParams) ([]SymbolInformation, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "workspace/symbol", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "workspace/symbol", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -231,11 +353,11 @@ func (client *Client) WorkspaceSymbol(ctx context.Context, param *WorkspaceSymbo
 }
 
 func (client *Client) WorkspaceExecuteCommand(ctx context.Context, param *ExecuteCommandParams) (json.RawMessage, *jsonrpc.ResponseError, error) {
-	return client.conn.SendRequest(ctx, "workspace/executeCommand", EncodeMessage(param))
+	return client.sendRequest(ctx, "workspace/executeCommand", param)
 }
 
 func (client *Client) WorkspaceWillCreateFiles(ctx context.Context, param *CreateFilesParams) (*WorkspaceEdit, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "workspace/willCreateFiles", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "workspace/willCreateFiles", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -248,7 +370,7 @@ func (client *Client) WorkspaceWillCreateFiles(ctx context.Context, param *Creat
 }
 
 func (client *Client) WorkspaceWillRenameFiles(ctx context.Context, param *RenameFilesParams) (*WorkspaceEdit, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "workspace/willRenameFiles", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "workspace/willRenameFiles", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -261,7 +383,7 @@ func (client *Client) WorkspaceWillRenameFiles(ctx context.Context, param *Renam
 }
 
 func (client *Client) WorkspaceWillDeleteFiles(ctx context.Context, param *DeleteFilesParams) (*WorkspaceEdit, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "workspace/willDeleteFiles", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "workspace/willDeleteFiles", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -274,7 +396,7 @@ func (client *Client) WorkspaceWillDeleteFiles(ctx context.Context, param *Delet
 }
 
 func (client *Client) TextDocumentWillSaveWaitUntil(ctx context.Context, param *WillSaveTextDocumentParams) ([]TextEdit, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/willSaveWaitUntil", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/willSaveWaitUntil", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -287,7 +409,7 @@ func (client *Client) TextDocumentWillSaveWaitUntil(ctx context.Context, param *
 }
 
 func (client *Client) TextDocumentCompletion(ctx context.Context, param *CompletionParams) (*CompletionList, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/completion", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/completion", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -311,7 +433,7 @@ func (client *Client) TextDocumentCompletion(ctx context.Context, param *Complet
 }
 
 func (client *Client) CompletionItemResolve(ctx context.Context, param *CompletionItem) (*CompletionItem, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "completionItem/resolve", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "completionItem/resolve", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -320,7 +442,7 @@ func (client *Client) CompletionItemResolve(ctx context.Context, param *Completi
 }
 
 func (client *Client) TextDocumentHover(ctx context.Context, param *HoverParams) (*Hover, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/hover", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/hover", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -333,7 +455,7 @@ func (client *Client) TextDocumentHover(ctx context.Context, param *HoverParams)
 }
 
 func (client *Client) TextDocumentSignatureHelp(ctx context.Context, param *SignatureHelpParams) (*SignatureHelp, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/signatureHelp", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/signatureHelp", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -346,7 +468,7 @@ func (client *Client) TextDocumentSignatureHelp(ctx context.Context, param *Sign
 }
 
 func (client *Client) TextDocumentDeclaration(ctx context.Context, param *DeclarationParams) ([]Location, []LocationLink, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/declaration", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/declaration", param)
 	if err != nil || respErr != nil {
 		return nil, nil, respErr, err
 	}
@@ -367,7 +489,7 @@ func (client *Client) TextDocumentDeclaration(ctx context.Context, param *Declar
 }
 
 func (client *Client) TextDocumentDefinition(ctx context.Context, param *DefinitionParams) ([]Location, []LocationLink, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/definition", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/definition", param)
 	if err != nil || respErr != nil {
 		return nil, nil, respErr, err
 	}
@@ -388,7 +510,7 @@ func (client *Client) TextDocumentDefinition(ctx context.Context, param *Definit
 }
 
 func (client *Client) TextDocumentTypeDefinition(ctx context.Context, param *TypeDefinitionParams) ([]Location, []LocationLink, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/typeDefinition", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/typeDefinition", param)
 	if err != nil || respErr != nil {
 		return nil, nil, respErr, err
 	}
@@ -409,7 +531,7 @@ func (client *Client) TextDocumentTypeDefinition(ctx context.Context, param *Typ
 }
 
 func (client *Client) TextDocumentImplementation(ctx context.Context, param *ImplementationParams) ([]Location, []LocationLink, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/implementation", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/implementation", param)
 	if err != nil || respErr != nil {
 		return nil, nil, respErr, err
 	}
@@ -430,7 +552,7 @@ func (client *Client) TextDocumentImplementation(ctx context.Context, param *Imp
 }
 
 func (client *Client) TextDocumentReferences(ctx context.Context, param *ReferenceParams) ([]Location, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/references", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/references", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -443,7 +565,7 @@ func (client *Client) TextDocumentReferences(ctx context.Context, param *Referen
 }
 
 func (client *Client) TextDocumentDocumentHighlight(ctx context.Context, param *DocumentHighlightParams) ([]DocumentHighlight, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/documentHighlight", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/documentHighlight", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -456,7 +578,7 @@ func (client *Client) TextDocumentDocumentHighlight(ctx context.Context, param *
 }
 
 func (client *Client) TextDocumentDocumentSymbol(ctx context.Context, param *DocumentSymbolParams) ([]DocumentSymbol, []SymbolInformation, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/documentSymbol", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/documentSymbol", param)
 	if err != nil || respErr != nil {
 		return nil, nil, respErr, err
 	}
@@ -473,7 +595,7 @@ func (client *Client) TextDocumentDocumentSymbol(ctx context.Context, param *Doc
 }
 
 func (client *Client) TextDocumentCodeAction(ctx context.Context, param *CodeActionParams) ([]CommandOrCodeAction, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/codeAction", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/codeAction", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -486,7 +608,7 @@ func (client *Client) TextDocumentCodeAction(ctx context.Context, param *CodeAct
 }
 
 func (client *Client) CodeActionResolve(ctx context.Context, param *CodeAction) (*CodeAction, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "codeAction/resolve", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "codeAction/resolve", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -495,7 +617,7 @@ func (client *Client) CodeActionResolve(ctx context.Context, param *CodeAction)
 }
 
 func (client *Client) TextDocumentCodeLens(ctx context.Context, param *CodeLensParams) ([]CodeLens, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/codeLens", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/codeLens", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -508,7 +630,7 @@ func (client *Client) TextDocumentCodeLens(ctx context.Context, param *CodeLensP
 }
 
 func (client *Client) CodeLensResolve(ctx context.Context, param *CodeLens) (*CodeLens, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "codeLens/resolve", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "codeLens/resolve", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -517,7 +639,7 @@ func (client *Client) CodeLensResolve(ctx context.Context, param *CodeLens) (*Co
 }
 
 func (client *Client) TextDocumentDocumentLink(ctx context.Context, param *DocumentLinkParams) ([]DocumentLink, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/documentLink", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/documentLink", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -530,7 +652,7 @@ func (client *Client) TextDocumentDocumentLink(ctx context.Context, param *Docum
 }
 
 func (client *Client) DocumentLinkResolve(ctx context.Context, param *DocumentLink) (*DocumentLink, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "documentLink/resolve", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "documentLink/resolve", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -539,7 +661,7 @@ func (client *Client) DocumentLinkResolve(ctx context.Context, param *DocumentLi
 }
 
 func (client *Client) TextDocumentDocumentColor(ctx context.Context, param *DocumentColorParams) ([]ColorInformation, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/documentColor", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/documentColor", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -548,7 +670,7 @@ func (client *Client) TextDocumentDocumentColor(ctx context.Context, param *Docu
 }
 
 func (client *Client) TextDocumentColorPresentation(ctx context.Context, param *ColorPresentationParams) ([]ColorPresentation, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/colorPresentation", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/colorPresentation", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -557,7 +679,7 @@ func (client *Client) TextDocumentColorPresentation(ctx context.Context, param *
 }
 
 func (client *Client) TextDocumentFormatting(ctx context.Context, param *DocumentFormattingParams) ([]TextEdit, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/formatting", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/formatting", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -570,7 +692,7 @@ func (client *Client) TextDocumentFormatting(ctx context.Context, param *Documen
 }
 
 func (client *Client) TextDocumentRangeFormatting(ctx context.Context, param *DocumentRangeFormattingParams) ([]TextEdit, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/rangeFormatting", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/rangeFormatting", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -583,7 +705,7 @@ func (client *Client) TextDocumentRangeFormatting(ctx context.Context, param *Do
 }
 
 func (client *Client) TextDocumentOnTypeFormatting(ctx context.Context, param *DocumentOnTypeFormattingParams) ([]TextEdit, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/onTypeFormatting", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/onTypeFormatting", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -596,7 +718,7 @@ func (client *Client) TextDocumentOnTypeFormatting(ctx context.Context, param *D
 }
 
 func (client *Client) TextDocumentRename(ctx context.Context, param *RenameParams) (*WorkspaceEdit, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/rename", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/rename", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -610,7 +732,7 @@ func (client *Client) TextDocumentRename(ctx context.Context, param *RenameParam
 
 func (client *Client) TextDocumentPrepareRename(ctx context.Context, param *PrepareRenameParams) (json.RawMessage, *jsonrpc.ResponseError, error) {
 	panic("unimplemented")
-	// _, _, err := client.conn.SendRequest(ctx, "textDocument/prepareRename", EncodeMessage(param))
+	// _, _, err := client.sendRequest(ctx, "textDocument/prepareRename", param)
 	// if err != nil || respErr!=nil{
 	// 	return nil, respErr, err
 	// }
@@ -619,7 +741,7 @@ func (client *Client) TextDocumentPrepareRename(ctx context.Context, param *Prep
 }
 
 func (client *Client) TextDocumentFoldingRange(ctx context.Context, param *FoldingRangeParams) ([]FoldingRange, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/foldingRange", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/foldingRange", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -632,7 +754,7 @@ func (client *Client) TextDocumentFoldingRange(ctx context.Context, param *Foldi
 }
 
 func (client *Client) TextDocumentSelectionRange(ctx context.Context, param *SelectionRangeParams) ([]SelectionRange, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/selectionRange", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/selectionRange", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -645,7 +767,7 @@ func (client *Client) TextDocumentSelectionRange(ctx context.Context, param *Sel
 }
 
 func (client *Client) TextDocumentPrepareCallHierarchy(ctx context.Context, param *CallHierarchyPrepareParams) ([]CallHierarchyItem, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/prepareCallHierarchy", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/prepareCallHierarchy", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -658,7 +780,7 @@ func (client *Client) TextDocumentPrepareCallHierarchy(ctx context.Context, para
 }
 
 func (client *Client) CallHierarchyIncomingCalls(ctx context.Context, param *CallHierarchyIncomingCallsParams) ([]CallHierarchyIncomingCall, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "callHierarchy/incomingCalls", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "callHierarchy/incomingCalls", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -671,7 +793,7 @@ func (client *Client) CallHierarchyIncomingCalls(ctx context.Context, param *Cal
 }
 
 func (client *Client) CallHierarchyOutgoingCalls(ctx context.Context, param *CallHierarchyOutgoingCallsParams) ([]CallHierarchyOutgoingCall, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "callHierarchy/outgoingCalls", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "callHierarchy/outgoingCalls", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -683,8 +805,47 @@ func (client *Client) CallHierarchyOutgoingCalls(ctx context.Context, param *Cal
 	return res, nil, json.Unmarshal(resp, &res)
 }
 
+func (client *Client) TextDocumentPrepareTypeHierarchy(ctx context.Context, param *TypeHierarchyPrepareParams) ([]TypeHierarchyItem, *jsonrpc.ResponseError, error) {
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/prepareTypeHierarchy", param)
+	if err != nil || respErr != nil {
+		return nil, respErr, err
+	}
+	// result: TypeHierarchyItem[] | null
+	if string(resp) == "null" {
+		return nil, nil, nil
+	}
+	var res []TypeHierarchyItem
+	return res, nil, json.Unmarshal(resp, &res)
+}
+
+func (client *Client) TypeHierarchySupertypes(ctx context.Context, param *TypeHierarchySupertypesParams) ([]TypeHierarchyItem, *jsonrpc.ResponseError, error) {
+	resp, respErr, err := client.sendRequest(ctx, "typeHierarchy/supertypes", param)
+	if err != nil || respErr != nil {
+		return nil, respErr, err
+	}
+	// result: TypeHierarchyItem[] | null
+	if string(resp) == "null" {
+		return nil, nil, nil
+	}
+	var res []TypeHierarchyItem
+	return res, nil, json.Unmarshal(resp, &res)
+}
+
+func (client *Client) TypeHierarchySubtypes(ctx context.Context, param *TypeHierarchySubtypesParams) ([]TypeHierarchyItem, *jsonrpc.ResponseError, error) {
+	resp, respErr, err := client.sendRequest(ctx, "typeHierarchy/subtypes", param)
+	if err != nil || respErr != nil {
+		return nil, respErr, err
+	}
+	// result: TypeHierarchyItem[] | null
+	if string(resp) == "null" {
+		return nil, nil, nil
+	}
+	var res []TypeHierarchyItem
+	return res, nil, json.Unmarshal(resp, &res)
+}
+
 func (client *Client) TextDocumentSemanticTokensFull(ctx context.Context, param *SemanticTokensParams) (*SemanticTokens, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/semanticTokens/full", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/semanticTokens/full", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -697,7 +858,7 @@ func (client *Client) TextDocumentSemanticTokensFull(ctx context.Context, param
 }
 
 func (client *Client) TextDocumentSemanticTokensFullDelta(ctx context.Context, param *SemanticTokensDeltaParams) (*SemanticTokens, *SemanticTokensDelta, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/semanticTokens/full/delta", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/semanticTokens/full/delta", param)
 	if err != nil || respErr != nil {
 		return nil, nil, respErr, err
 	}
@@ -714,7 +875,7 @@ func (client *Client) TextDocumentSemanticTokensFullDelta(ctx context.Context, p
 }
 
 func (client *Client) TextDocumentSemanticTokensRange(ctx context.Context, param *SemanticTokensRangeParams) (*SemanticTokens, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/semanticTokens/range", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/semanticTokens/range", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -727,12 +888,12 @@ func (client *Client) TextDocumentSemanticTokensRange(ctx context.Context, param
 }
 
 func (client *Client) WorkspaceSemanticTokensRefresh(ctx context.Context) (*jsonrpc.ResponseError, error) {
-	_, respErr, err := client.conn.SendRequest(ctx, "workspace/semanticTokens/refresh", EncodeMessage(jsonrpc.NullResult))
+	_, respErr, err := client.sendRequest(ctx, "workspace/semanticTokens/refresh", jsonrpc.NullResult)
 	return respErr, err
 }
 
 func (client *Client) TextDocumentLinkedEditingRange(ctx context.Context, param *LinkedEditingRangeParams) (*LinkedEditingRanges, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/linkedEditingRange", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/linkedEditingRange", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -745,7 +906,7 @@ func (client *Client) TextDocumentLinkedEditingRange(ctx context.Context, param
 }
 
 func (client *Client) TextDocumentMoniker(ctx context.Context, param *MonikerParams) ([]Moniker, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := client.conn.SendRequest(ctx, "textDocument/moniker", EncodeMessage(param))
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/moniker", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -757,68 +918,137 @@ func (client *Client) TextDocumentMoniker(ctx context.Context, param *MonikerPar
 	return res, nil, json.Unmarshal(resp, &res)
 }
 
+func (client *Client) TextDocumentInlineValue(ctx context.Context, param *InlineValueParams) ([]InlineValue, *jsonrpc.ResponseError, error) {
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/inlineValue", param)
+	if err != nil || respErr != nil {
+		return nil, respErr, err
+	}
+	// result: InlineValue[] | null
+	if string(resp) == "null" {
+		return nil, nil, nil
+	}
+	var res []InlineValue
+	return res, nil, json.Unmarshal(resp, &res)
+}
+
+func (client *Client) TextDocumentInlayHint(ctx context.Context, param *InlayHintParams) ([]InlayHint, *jsonrpc.ResponseError, error) {
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/inlayHint", param)
+	if err != nil || respErr != nil {
+		return nil, respErr, err
+	}
+	// result: InlayHint[] | null
+	if string(resp) == "null" {
+		return nil, nil, nil
+	}
+	var res []InlayHint
+	return res, nil, json.Unmarshal(resp, &res)
+}
+
+func (client *Client) InlayHintResolve(ctx context.Context, param *InlayHint) (*InlayHint, *jsonrpc.ResponseError, error) {
+	resp, respErr, err := client.sendRequest(ctx, "inlayHint/resolve", param)
+	if err != nil || respErr != nil {
+		return nil, respErr, err
+	}
+	var res InlayHint
+	return &res, nil, json.Unmarshal(resp, &res)
+}
+
+func (client *Client) TextDocumentDiagnostic(ctx context.Context, param *DocumentDiagnosticParams) (*DocumentDiagnosticReport, *jsonrpc.ResponseError, error) {
+	resp, respErr, err := client.sendRequest(ctx, "textDocument/diagnostic", param)
+	if err != nil || respErr != nil {
+		return nil, respErr, err
+	}
+	var res DocumentDiagnosticReport
+	return &res, nil, json.Unmarshal(resp, &res)
+}
+
+func (client *Client) WorkspaceDiagnostic(ctx context.Context, param *WorkspaceDiagnosticParams) (*WorkspaceDiagnosticReport, *jsonrpc.ResponseError, error) {
+	resp, respErr, err := client.sendRequest(ctx, "workspace/diagnostic", param)
+	if err != nil || respErr != nil {
+		return nil, respErr, err
+	}
+	var res WorkspaceDiagnosticReport
+	return &res, nil, json.Unmarshal(resp, &res)
+}
+
 // Notifications to Server
 
 func (client *Client) Progress(param *ProgressParams) error {
-	return client.conn.SendNotification("$/progress", EncodeMessage(param))
+	return client.sendNotification("$/progress", param)
 }
 
 func (client *Client) Initialized(param *InitializedParams) error {
-	return client.conn.SendNotification("initialized", EncodeMessage(param))
+	return client.sendNotification("initialized", param)
 }
 
 func (client *Client) Exit() error {
-	return client.conn.SendNotification("exit", EncodeMessage(jsonrpc.NullResult))
+	return client.sendNotification("exit", jsonrpc.NullResult)
 }
 
 func (client *Client) SetTrace(param *SetTraceParams) error {
-	return client.conn.SendNotification("$/setTrace", EncodeMessage(param))
+	return client.sendNotification("$/setTrace", param)
 }
 
 func (client *Client) WindowWorkDoneProgressCancel(param *WorkDoneProgressCancelParams) error {
-	return client.conn.SendNotification("window/workDoneProgress/cancel", EncodeMessage(param))
+	return client.sendNotification("window/workDoneProgress/cancel", param)
 }
 
 func (client *Client) WorkspaceDidChangeWorkspaceFolders(param *DidChangeWorkspaceFoldersParams) error {
-	return client.conn.SendNotification("workspace/didChangeWorkspaceFolders", EncodeMessage(param))
+	return client.sendNotification("workspace/didChangeWorkspaceFolders", param)
 }
 
 func (client *Client) WorkspaceDidChangeConfiguration(param *DidChangeConfigurationParams) error {
-	return client.conn.SendNotification("workspace/didChangeConfiguration", EncodeMessage(param))
+	return client.sendNotification("workspace/didChangeConfiguration", param)
 }
 
 func (client *Client) WorkspaceDidChangeWatchedFiles(param *DidChangeWatchedFilesParams) error {
-	return client.conn.SendNotification("workspace/didChangeWatchedFiles", EncodeMessage(param))
+	return client.sendNotification("workspace/didChangeWatchedFiles", param)
 }
 
 func (client *Client) WorkspaceDidCreateFiles(param *CreateFilesParams) error {
-	return client.conn.SendNotification("workspace/didCreateFiles", EncodeMessage(param))
+	return client.sendNotification("workspace/didCreateFiles", param)
 }
 
 func (client *Client) WorkspaceDidRenameFiles(param *RenameFilesParams) error {
-	return client.conn.SendNotification("workspace/didRenameFiles", EncodeMessage(param))
+	return client.sendNotification("workspace/didRenameFiles", param)
 }
 
 func (client *Client) WorkspaceDidDeleteFiles(param *DeleteFilesParams) error {
-	return client.conn.SendNotification("workspace/didDeleteFiles", EncodeMessage(param))
+	return client.sendNotification("workspace/didDeleteFiles", param)
 }
 
 func (client *Client) TextDocumentDidOpen(param *DidOpenTextDocumentParams) error {
-	return client.conn.SendNotification("textDocument/didOpen", EncodeMessage(param))
+	return client.sendNotification("textDocument/didOpen", param)
 }
 
 func (client *Client) TextDocumentDidChange(param *DidChangeTextDocumentParams) error {
-	return client.conn.SendNotification("textDocument/didChange", EncodeMessage(param))
+	return client.sendNotification("textDocument/didChange", param)
 }
 
 func (client *Client) TextDocumentWillSave(param *WillSaveTextDocumentParams) error {
-	return client.conn.SendNotification("textDocument/willSave", EncodeMessage(param))
+	return client.sendNotification("textDocument/willSave", param)
 }
 
 func (client *Client) TextDocumentDidSave(param *DidSaveTextDocumentParams) error {
-	return client.conn.SendNotification("textDocument/didSave", EncodeMessage(param))
+	return client.sendNotification("textDocument/didSave", param)
 }
 
 func (client *Client) TextDocumentDidClose(param *DidCloseTextDocumentParams) error {
-	return client.conn.SendNotification("textDocument/didClose", EncodeMessage(param))
+	return client.sendNotification("textDocument/didClose", param)
+}
+
+func (client *Client) NotebookDocumentDidOpen(param *DidOpenNotebookDocumentParams) error {
+	return client.sendNotification("notebookDocument/didOpen", param)
+}
+
+func (client *Client) NotebookDocumentDidChange(param *DidChangeNotebookDocumentParams) error {
+	return client.sendNotification("notebookDocument/didChange", param)
+}
+
+func (client *Client) NotebookDocumentDidSave(param *DidSaveNotebookDocumentParams) error {
+	return client.sendNotification("notebookDocument/didSave", param)
+}
+
+func (client *Client) NotebookDocumentDidClose(param *DidCloseNotebookDocumentParams) error {
+	return client.sendNotification("notebookDocument/didClose", param)
 }
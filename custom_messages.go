@@ -0,0 +1,86 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"context"
+	"fmt"
+
+	"go.bug.st/json"
+	"go.bug.st/lsp/jsonrpc"
+)
+
+// Endpoint is one side of an LSP connection: either a *Server or a *Client.
+// It is used by the generic helpers to exchange custom messages.
+type Endpoint interface {
+	RegisterCustomRequest(method string, callback CustomRequest)
+	RegisterCustomNotification(method string, callback CustomNotification)
+
+	sendRequest(ctx context.Context, method string, params any) (json.RawMessage, *jsonrpc.ResponseError, error)
+	sendNotification(method string, params any) error
+	reportError(err error)
+}
+
+var _ Endpoint = (*Server)(nil)
+var _ Endpoint = (*Client)(nil)
+
+// RegisterRequest registers a handler for the custom request method. The
+// params are decoded into a P (an InvalidParams error is sent back if they
+// can't be decoded) and the result R is encoded in the response (an
+// InternalError is sent back if it can't be encoded).
+func RegisterRequest[P, R any](e Endpoint, method string, handler func(ctx context.Context, logger jsonrpc.FunctionLogger, params *P) (R, *jsonrpc.ResponseError)) {
+	e.RegisterCustomRequest(method, func(ctx context.Context, logger jsonrpc.FunctionLogger, req json.RawMessage) (interface{}, *jsonrpc.ResponseError) {
+		var params P
+		if err := decodeParams(req, &params); err != nil {
+			return nil, invalidParamsError(err)
+		}
+		return handler(ctx, logger, &params)
+	})
+}
+
+// RegisterNotification registers a handler for the custom notification
+// method. The params are decoded into a P, if they can't be decoded the
+// error is reported to the error handler and the notification is dropped.
+func RegisterNotification[P any](e Endpoint, method string, handler func(logger jsonrpc.FunctionLogger, params *P)) {
+	e.RegisterCustomNotification(method, func(logger jsonrpc.FunctionLogger, req json.RawMessage) {
+		var params P
+		if err := decodeParams(req, &params); err != nil {
+			e.reportError(fmt.Errorf("invalid params for %s: %w", method, err))
+			return
+		}
+		handler(logger, &params)
+	})
+}
+
+// SendRequest sends the custom request method to the other side and waits
+// for the response. The params are encoded and the result is decoded into
+// an R: the encoding and decoding failures are returned as error.
+func SendRequest[P, R any](e Endpoint, ctx context.Context, method string, params *P) (R, *jsonrpc.ResponseError, error) {
+	var res R
+	resp, respErr, err := e.sendRequest(ctx, method, params)
+	if err != nil || respErr != nil {
+		return res, respErr, err
+	}
+	if err := json.Unmarshal(resp, &res); err != nil {
+		return res, nil, fmt.Errorf("could not decode result of %s: %w", method, err)
+	}
+	return res, nil, nil
+}
+
+// SendNotification sends the custom notification method to the other side.
+func SendNotification[P any](e Endpoint, method string, params *P) error {
+	return e.sendNotification(method, params)
+}
+
+// decodeParams decodes the params of a message, missing params are decoded
+// as the zero value.
+func decodeParams(req json.RawMessage, params any) error {
+	if len(req) == 0 || string(req) == "null" {
+		return nil
+	}
+	return json.Unmarshal(req, params)
+}
@@ -0,0 +1,75 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.bug.st/lsp/jsonrpc"
+)
+
+type customParams struct {
+	Value int `json:"value"`
+}
+
+type customResult struct {
+	Double int `json:"double"`
+}
+
+func TestCustomMessages(t *testing.T) {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	serv := NewServer(serverIn, serverOut, UnimplementedClientMessagesHandler{})
+	client := NewClient(clientIn, clientOut, UnimplementedServerMessagesHandler{})
+
+	RegisterRequest(serv, "custom/double", func(ctx context.Context, logger jsonrpc.FunctionLogger, params *customParams) (*customResult, *jsonrpc.ResponseError) {
+		return &customResult{Double: params.Value * 2}, nil
+	})
+	RegisterRequest(serv, "custom/broken", func(ctx context.Context, logger jsonrpc.FunctionLogger, params *customParams) (func(), *jsonrpc.ResponseError) {
+		return func() {}, nil
+	})
+	status := make(chan string, 1)
+	RegisterNotification(client, "custom/status", func(logger jsonrpc.FunctionLogger, params *struct{ Status string }) {
+		status <- params.Status
+	})
+
+	go serv.Run()
+	go client.Run()
+	defer client.Close()
+	defer serv.Close()
+	ctx := context.Background()
+
+	res, resErr, err := SendRequest[customParams, customResult](client, ctx, "custom/double", &customParams{Value: 21})
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	require.Equal(t, 42, res.Double)
+
+	invalidParams := "invalid"
+	_, resErr, err = SendRequest[string, customResult](client, ctx, "custom/double", &invalidParams)
+	require.NoError(t, err)
+	require.Equal(t, jsonrpc.ErrorCodesInvalidParams, resErr.Code)
+
+	_, resErr, err = SendRequest[customParams, customResult](client, ctx, "custom/broken", &customParams{})
+	require.NoError(t, err)
+	require.Equal(t, jsonrpc.ErrorCodesInternalError, resErr.Code)
+
+	unencodableParams := func() {}
+	_, _, err = SendRequest[func(), customResult](client, ctx, "custom/double", &unencodableParams)
+	require.Error(t, err)
+
+	require.NoError(t, SendNotification(serv, "custom/status", &struct{ Status string }{Status: "ready"}))
+	select {
+	case s := <-status:
+		require.Equal(t, "ready", s)
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification not received")
+	}
+}
@@ -5,6 +5,7 @@ go 1.22
 require (
 	github.com/arduino/go-paths-helper v1.6.1
 	github.com/davecgh/go-spew v1.1.0
+	github.com/gorilla/websocket v1.5.3
 	github.com/stretchr/testify v1.6.1
 	go.bug.st/json v1.15.6
 )
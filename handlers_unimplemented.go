@@ -0,0 +1,99 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"context"
+
+	"go.bug.st/json"
+	"go.bug.st/lsp/jsonrpc"
+)
+
+// UnimplementedClientMessagesHandler is a ClientMessagesHandler where every
+// request is answered with a MethodNotFound error and every notification is
+// ignored, except the shutdown request that succeeds without doing anything.
+// It should be embedded in the handlers of a Server, that can then implement
+// only the supported methods. Only the ClientMessagesHandler methods are
+// implemented here: the optional feature interfaces (HoverProvider, etc.) are
+// left out so that the Server can detect the features actually supported, and
+// answer MethodNotFound for the others.
+type UnimplementedClientMessagesHandler struct{}
+
+var _ ClientMessagesHandler = UnimplementedClientMessagesHandler{}
+
+func (UnimplementedClientMessagesHandler) Initialize(context.Context, jsonrpc.FunctionLogger, *InitializeParams) (*InitializeResult, *jsonrpc.ResponseError) {
+	return nil, methodNotFoundError("initialize")
+}
+
+func (UnimplementedClientMessagesHandler) Shutdown(context.Context, jsonrpc.FunctionLogger) *jsonrpc.ResponseError {
+	// The lifecycle requires the shutdown to succeed before the exit, a
+	// MethodNotFound error would prevent a clean termination of the session.
+	return nil
+}
+
+func (UnimplementedClientMessagesHandler) Initialized(jsonrpc.FunctionLogger, *InitializedParams) {}
+
+func (UnimplementedClientMessagesHandler) Exit(jsonrpc.FunctionLogger) {}
+
+// UnimplementedServerMessagesHandler is a ServerMessagesHandler where every
+// request is answered with a MethodNotFound error and every notification is
+// ignored. It should be embedded in the handlers of a Client, that can then
+// implement only the supported methods.
+type UnimplementedServerMessagesHandler struct{}
+
+var _ ServerMessagesHandler = UnimplementedServerMessagesHandler{}
+
+func (UnimplementedServerMessagesHandler) WindowShowMessageRequest(context.Context, jsonrpc.FunctionLogger, *ShowMessageRequestParams) (*MessageActionItem, *jsonrpc.ResponseError) {
+	return nil, methodNotFoundError("window/showMessageRequest")
+}
+
+func (UnimplementedServerMessagesHandler) WindowShowDocument(context.Context, jsonrpc.FunctionLogger, *ShowDocumentParams) (*ShowDocumentResult, *jsonrpc.ResponseError) {
+	return nil, methodNotFoundError("window/showDocument")
+}
+
+func (UnimplementedServerMessagesHandler) WindowWorkDoneProgressCreate(context.Context, jsonrpc.FunctionLogger, *WorkDoneProgressCreateParams) *jsonrpc.ResponseError {
+	return methodNotFoundError("window/workDoneProgress/create")
+}
+
+func (UnimplementedServerMessagesHandler) ClientRegisterCapability(context.Context, jsonrpc.FunctionLogger, *RegistrationParams) *jsonrpc.ResponseError {
+	return methodNotFoundError("client/registerCapability")
+}
+
+func (UnimplementedServerMessagesHandler) ClientUnregisterCapability(context.Context, jsonrpc.FunctionLogger, *UnregistrationParams) *jsonrpc.ResponseError {
+	return methodNotFoundError("client/unregisterCapability")
+}
+
+func (UnimplementedServerMessagesHandler) WorkspaceWorkspaceFolders(context.Context, jsonrpc.FunctionLogger) ([]WorkspaceFolder, *jsonrpc.ResponseError) {
+	return nil, methodNotFoundError("workspace/workspaceFolders")
+}
+
+func (UnimplementedServerMessagesHandler) WorkspaceConfiguration(context.Context, jsonrpc.FunctionLogger, *ConfigurationParams) ([]json.RawMessage, *jsonrpc.ResponseError) {
+	return nil, methodNotFoundError("workspace/configuration")
+}
+
+func (UnimplementedServerMessagesHandler) WorkspaceApplyEdit(context.Context, jsonrpc.FunctionLogger, *ApplyWorkspaceEditParams) (*ApplyWorkspaceEditResult, *jsonrpc.ResponseError) {
+	return nil, methodNotFoundError("workspace/applyEdit")
+}
+
+func (UnimplementedServerMessagesHandler) WorkspaceCodeLensRefresh(context.Context, jsonrpc.FunctionLogger) *jsonrpc.ResponseError {
+	return methodNotFoundError("workspace/codeLens/refresh")
+}
+
+func (UnimplementedServerMessagesHandler) Progress(jsonrpc.FunctionLogger, *ProgressParams) {}
+
+func (UnimplementedServerMessagesHandler) LogTrace(jsonrpc.FunctionLogger, *LogTraceParams) {}
+
+func (UnimplementedServerMessagesHandler) WindowShowMessage(jsonrpc.FunctionLogger, *ShowMessageParams) {
+}
+
+func (UnimplementedServerMessagesHandler) WindowLogMessage(jsonrpc.FunctionLogger, *LogMessageParams) {
+}
+
+func (UnimplementedServerMessagesHandler) TelemetryEvent(jsonrpc.FunctionLogger, json.RawMessage) {}
+
+func (UnimplementedServerMessagesHandler) TextDocumentPublishDiagnostics(jsonrpc.FunctionLogger, *PublishDiagnosticsParams) {
+}
@@ -0,0 +1,48 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnimplementedHandlers(t *testing.T) {
+	input := encodeFrames(
+		`{"jsonrpc":"2.0","id":1,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.txt"},"position":{"line":0,"character":0}}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///a.txt","languageId":"text","version":1,"text":""}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"shutdown"}`,
+	)
+	output := &bytes.Buffer{}
+	errs := []string{}
+	serv := NewServer(strings.NewReader(input), output, UnimplementedClientMessagesHandler{})
+	serv.SetErrorHandler(func(e error) { errs = append(errs, e.Error()) })
+	serv.Run()
+	require.Equal(t, ""+
+		encodeFrames(
+			`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found: textDocument/hover"}}`,
+			`{"jsonrpc":"2.0","id":2,"result":null}`,
+		), output.String())
+	require.Equal(t, []string{"EOF"}, errs)
+
+	input = encodeFrames(
+		`{"jsonrpc":"2.0","id":1,"method":"window/showDocument","params":{"uri":"file:///a.txt"}}`,
+		`{"jsonrpc":"2.0","method":"window/logMessage","params":{"type":1,"message":"hello"}}`,
+	)
+	output = &bytes.Buffer{}
+	errs = []string{}
+	client := NewClient(strings.NewReader(input), output, &UnimplementedServerMessagesHandler{})
+	client.SetErrorHandler(func(e error) { errs = append(errs, e.Error()) })
+	client.Run()
+	require.Equal(t, encodeFrames(
+		`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found: window/showDocument"}}`,
+	), output.String())
+	require.Equal(t, []string{"EOF"}, errs)
+}
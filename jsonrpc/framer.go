@@ -0,0 +1,148 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strconv"
+)
+
+// Framer reads and writes the JSON-RPC messages from and to the wire.
+// ReadMessage is called only by the read loop of the Connection, while the
+// calls to WriteMessage are serialized by the Connection.
+// If the Framer implements io.Closer it is closed together with the
+// Connection.
+type Framer interface {
+	// ReadMessage returns the next message received.
+	ReadMessage() ([]byte, error)
+
+	// WriteMessage sends a message.
+	WriteMessage(data []byte) error
+}
+
+// FramerFactory creates a Framer that reads from in and writes to out.
+type FramerFactory func(in io.Reader, out io.Writer) Framer
+
+// headerFramer implements the base protocol of LSP: each message is preceded
+// by an header part with the Content-Length of the message.
+type headerFramer struct {
+	rawIn io.Reader
+	in    *textproto.Reader
+	out   io.Writer
+}
+
+// NewHeaderFramer returns a Framer that sends and receives the messages
+// preceded by a Content-Length header, as defined by the LSP base protocol.
+// This is the default Framer for NewConnection.
+func NewHeaderFramer(in io.Reader, out io.Writer) Framer {
+	return &headerFramer{
+		rawIn: in,
+		in:    textproto.NewReader(bufio.NewReader(in)),
+		out:   out,
+	}
+}
+
+func (f *headerFramer) ReadMessage() ([]byte, error) {
+	head, err := f.in.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	httpHeader := http.Header(head)
+	l := httpHeader.Get("Content-Length")
+	dataLen, err := strconv.Atoi(l)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonData := make([]byte, dataLen)
+	if _, err := io.ReadFull(f.in.R, jsonData); err != nil {
+		return nil, err
+	}
+	return jsonData, nil
+}
+
+func (f *headerFramer) WriteMessage(data []byte) error {
+	if _, err := fmt.Fprintf(f.out, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+		return err
+	}
+	return writeAll(f.out, data)
+}
+
+func (f *headerFramer) Close() error {
+	return closeStreams(f.rawIn, f.out)
+}
+
+// newlineFramer implements the newline-delimited JSON framing.
+type newlineFramer struct {
+	rawIn io.Reader
+	in    *bufio.Reader
+	out   io.Writer
+}
+
+// NewNewlineFramer returns a Framer that sends and receives the messages
+// as newline-delimited JSON: each message is written on a single line.
+// Empty lines are ignored.
+func NewNewlineFramer(in io.Reader, out io.Writer) Framer {
+	return &newlineFramer{
+		rawIn: in,
+		in:    bufio.NewReader(in),
+		out:   out,
+	}
+}
+
+func (f *newlineFramer) ReadMessage() ([]byte, error) {
+	for {
+		line, err := f.in.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(bytes.TrimSpace(line)) == 0) {
+			return nil, err
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			return line, nil
+		}
+	}
+}
+
+func (f *newlineFramer) WriteMessage(data []byte) error {
+	if bytes.ContainsRune(data, '\n') {
+		return errors.New("message contains a newline")
+	}
+	return writeAll(f.out, append(data, '\n'))
+}
+
+func (f *newlineFramer) Close() error {
+	return closeStreams(f.rawIn, f.out)
+}
+
+func writeAll(out io.Writer, buff []byte) error {
+	for len(buff) > 0 {
+		n, err := out.Write(buff)
+		if err != nil {
+			return err
+		}
+		buff = buff[n:]
+	}
+	return nil
+}
+
+// closeStreams closes the given streams if they implement io.Closer.
+func closeStreams(in io.Reader, out io.Writer) error {
+	var errs []error
+	if closer, ok := in.(io.Closer); ok {
+		errs = append(errs, closer.Close())
+	}
+	if closer, ok := out.(io.Closer); ok {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
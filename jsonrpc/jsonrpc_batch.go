@@ -0,0 +1,199 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"go.bug.st/json"
+)
+
+// BatchMessage is a request or a notification to be sent in a batch
+// with SendBatch.
+type BatchMessage struct {
+	// The method to be invoked.
+	Method string
+
+	// The method's params.
+	Params json.RawMessage
+
+	// Notification is true if the message is a notification, notifications
+	// do not get a response.
+	Notification bool
+}
+
+// BatchResponse is the response to a request sent with SendBatch.
+type BatchResponse struct {
+	// The result of the request.
+	Result json.RawMessage
+
+	// The error returned by the peer, if any.
+	Error *ResponseError
+}
+
+// incomingBatch collects the responses to the requests of an incoming batch,
+// the responses are sent back all together in a single batch.
+type incomingBatch struct {
+	conn      *Connection
+	mutex     sync.Mutex
+	pending   int
+	requests  int
+	responses []*ResponseMessage
+}
+
+// isBatch returns true if the JSON data is an array.
+func isBatch(jsonData []byte) bool {
+	trimmed := bytes.TrimSpace(jsonData)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+func (c *Connection) handleIncomingBatch(jsonData []byte) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(jsonData, &elements); err != nil {
+		c.errorHandler(fmt.Errorf("invalid batch: %s", string(jsonData)))
+		c.sendResponse(&ResponseMessage{JSONRPC: "2.0", Error: &ResponseError{Code: ErrorCodesParseError, Message: "parse error"}})
+		return
+	}
+	if len(elements) == 0 {
+		c.errorHandler(errors.New("invalid batch: empty array"))
+		c.sendResponse(&ResponseMessage{JSONRPC: "2.0", Error: &ResponseError{Code: ErrorCodesInvalidRequest, Message: "invalid request"}})
+		return
+	}
+
+	// The batch is flushed when all the requests have been answered, one
+	// more pending slot is reserved until all the messages are dispatched.
+	batch := &incomingBatch{conn: c, pending: 1}
+	msgs := make([]any, len(elements))
+	for i, element := range elements {
+		msg, msgErr := decodeMessage(element)
+		if msgErr != nil {
+			c.errorHandler(fmt.Errorf("invalid request in batch: %s", string(element)))
+			batch.responses = append(batch.responses, &ResponseMessage{JSONRPC: "2.0", Error: msgErr})
+			continue
+		}
+		if _, ok := msg.(*RequestMessage); ok {
+			batch.pending++
+			batch.requests++
+		}
+		msgs[i] = msg
+	}
+
+	for _, msg := range msgs {
+		switch msg := msg.(type) {
+		case *RequestMessage:
+			c.handleIncomingRequest(context.Background(), msg, batch.addResponse)
+		case *NotificationMessage:
+			c.handleIncomingNotification(msg)
+		case *ResponseMessage:
+			c.handleIncomingResponse(msg)
+		}
+	}
+	batch.addResponse(nil)
+}
+
+// addResponse adds a response to the batch, the batch is sent once all the
+// responses are available. A nil response releases the slot reserved during
+// the dispatch of the batch.
+func (b *incomingBatch) addResponse(resp *ResponseMessage) {
+	b.mutex.Lock()
+	if resp != nil {
+		b.responses = append(b.responses, resp)
+	}
+	b.pending--
+	completed := b.pending == 0
+	b.mutex.Unlock()
+	if !completed {
+		return
+	}
+
+	// A batch of notifications only does not get any response
+	if len(b.responses) > 0 {
+		b.conn.sendResponse(b.responses)
+	}
+	b.conn.responsesSent(b.requests)
+}
+
+// SendBatch sends the given requests and notifications in a single batch and
+// waits for all the responses. The returned slice has the same length of msgs,
+// and contains the response of each request at the same index of the request
+// (the entries corresponding to notifications are left empty).
+// If the context is done before all the responses are received, the pending
+// requests are canceled.
+func (c *Connection) SendBatch(ctx context.Context, msgs []BatchMessage) ([]BatchResponse, error) {
+	if len(msgs) == 0 {
+		return nil, errors.New("empty batch")
+	}
+
+	batch := make([]any, len(msgs))
+	ids := make([]RequestID, len(msgs))
+	resultChans := make([]chan *outResponse, len(msgs))
+	for i, msg := range msgs {
+		if msg.Notification {
+			batch[i] = NotificationMessage{
+				JSONRPC: "2.0",
+				Method:  msg.Method,
+				Params:  msg.Params,
+			}
+			c.loggerMutex.Lock()
+			c.logger.LogOutgoingNotification(msg.Method, msg.Params)
+			c.loggerMutex.Unlock()
+			continue
+		}
+		ids[i] = NewIntRequestID(int64(atomic.AddUint64(&c.lastOutRequestsIndex, 1)))
+		resultChans[i] = make(chan *outResponse, 1)
+		batch[i] = RequestMessage{
+			JSONRPC: "2.0",
+			ID:      ids[i],
+			Method:  msg.Method,
+			Params:  msg.Params,
+		}
+		c.loggerMutex.Lock()
+		c.logger.LogOutgoingRequest(ids[i], msg.Method, msg.Params)
+		c.loggerMutex.Unlock()
+	}
+
+	c.activeOutRequestsMutex.Lock()
+	if c.IsClosed() {
+		c.activeOutRequestsMutex.Unlock()
+		return nil, &ConnectionClosedError{}
+	}
+	requestIDs := []RequestID{}
+	for i, msg := range msgs {
+		if !msg.Notification {
+			c.activeOutRequests[ids[i]] = &outRequest{
+				resultChan: resultChans[i],
+				method:     msg.Method,
+			}
+			requestIDs = append(requestIDs, ids[i])
+		}
+	}
+	c.activeOutRequestsMutex.Unlock()
+
+	// The batch is written without holding the lock, see SendRequest
+	if err := c.send(batch); err != nil {
+		c.removeOutRequests(requestIDs...)
+		return nil, fmt.Errorf("sending batch: %w", err)
+	}
+
+	res := make([]BatchResponse, len(msgs))
+	for i, msg := range msgs {
+		if msg.Notification {
+			continue
+		}
+		result, resultErr, err := c.waitResponse(ctx, ids[i], msg.Method, resultChans[i])
+		if err != nil {
+			return nil, err
+		}
+		res[i] = BatchResponse{Result: result, Error: resultErr}
+	}
+	return res, nil
+}
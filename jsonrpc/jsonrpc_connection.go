@@ -7,13 +7,10 @@
 package jsonrpc
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"io"
-	"net/http"
-	"net/textproto"
-	"strconv"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -23,8 +20,7 @@ import (
 
 // Connection is a JSON RPC connection for LSP protocol
 type Connection struct {
-	in                  *bufio.Reader
-	out                 io.Writer
+	framer              Framer
 	outMutex            sync.Mutex
 	errorHandler        func(error)
 	requestHandler      RequestHandler
@@ -32,12 +28,25 @@ type Connection struct {
 	logger              Logger
 	loggerMutex         sync.Mutex
 
-	activeInRequests      map[string]*inRequest
+	activeInRequests      map[RequestID]*inRequest
 	activeInRequestsMutex sync.Mutex
+	pendingResponses      int
+	responsesFlushed      chan struct{}
 
-	activeOutRequests      map[string]*outRequest
+	activeOutRequests      map[RequestID]*outRequest
 	activeOutRequestsMutex sync.Mutex
 	lastOutRequestsIndex   uint64
+
+	requestsConcurrency int
+	requestsSemaphore   chan struct{}
+
+	// dispatchMutex serializes the dispatch of the messages read from the
+	// peer with the dispatch of the local messages.
+	dispatchMutex        sync.Mutex
+	lastLocalRequestsIdx uint64
+
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
 type inRequest struct {
@@ -52,6 +61,61 @@ type outRequest struct {
 type outResponse struct {
 	reqResult json.RawMessage
 	reqError  *ResponseError
+	err       error
+}
+
+// ConnectionClosedError is the error returned to the pending (and to the
+// subsequent) outgoing requests when the Connection is closed.
+type ConnectionClosedError struct {
+	// Cause is the error that caused the closing of the connection, or nil
+	// if the connection has been explicitly closed.
+	Cause error
+}
+
+func (e *ConnectionClosedError) Error() string {
+	if e.Cause == nil {
+		return "connection closed"
+	}
+	return fmt.Sprintf("connection closed: %s", e.Cause)
+}
+
+func (e *ConnectionClosedError) Unwrap() error {
+	return e.Cause
+}
+
+// HandlerPanicError is reported to the error handler when a request or a
+// notification handler panics. The panic is recovered and, in case of
+// a request, the peer receives an InternalError response.
+type HandlerPanicError struct {
+	// Method is the method of the request or notification being handled.
+	Method string
+
+	// Value is the value passed to panic.
+	Value any
+
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+func (e *HandlerPanicError) Error() string {
+	return fmt.Sprintf("panic in %s handler: %v", e.Method, e.Value)
+}
+
+// ResponseError returns the InternalError response for the panic, the panic
+// message and the stack trace are available in the Data field.
+func (e *HandlerPanicError) ResponseError() *ResponseError {
+	data, _ := json.Marshal(struct {
+		Panic string `json:"panic"`
+		Stack string `json:"stack"`
+	}{
+		Panic: fmt.Sprint(e.Value),
+		Stack: string(e.Stack),
+	})
+	return &ResponseError{
+		Code:    ErrorCodesInternalError,
+		Message: e.Error(),
+		Data:    data,
+	}
 }
 
 // RequestHandler handles requests from a jsonrpc Connection.
@@ -60,17 +124,24 @@ type RequestHandler func(ctx context.Context, logger FunctionLogger, method stri
 // NotificationHandler handles notifications from a jsonrpc Connection.
 type NotificationHandler func(logger FunctionLogger, method string, params json.RawMessage)
 
-// NewConnection starts a new
+// NewConnection creates a new Connection that reads from in and writes to out
+// using the LSP base protocol framing (see NewHeaderFramer).
 func NewConnection(in io.Reader, out io.Writer, requestHandler RequestHandler, notificationHandler NotificationHandler, errorHandler func(error)) *Connection {
+	return NewConnectionWithFramer(NewHeaderFramer(in, out), requestHandler, notificationHandler, errorHandler)
+}
+
+// NewConnectionWithFramer creates a new Connection that uses the given Framer
+// to send and receive the messages.
+func NewConnectionWithFramer(framer Framer, requestHandler RequestHandler, notificationHandler NotificationHandler, errorHandler func(error)) *Connection {
 	conn := &Connection{
-		in:                  bufio.NewReader(in),
-		out:                 out,
+		framer:              framer,
 		requestHandler:      requestHandler,
 		notificationHandler: notificationHandler,
 		errorHandler:        errorHandler,
-		activeInRequests:    map[string]*inRequest{},
-		activeOutRequests:   map[string]*outRequest{},
+		activeInRequests:    map[RequestID]*inRequest{},
+		activeOutRequests:   map[RequestID]*outRequest{},
 		logger:              NullLogger{},
+		closed:              make(chan struct{}),
 	}
 	return conn
 }
@@ -81,34 +152,54 @@ func (c *Connection) SetLogger(l Logger) {
 	c.loggerMutex.Unlock()
 }
 
+// SetRequestsConcurrency sets how the incoming requests are dispatched to the
+// RequestHandler:
+//   - if n is 0 (the default) each request is handled synchronously in the
+//     read loop, the next message is not read until the handler returns;
+//   - if n is negative each request is handled in its own goroutine;
+//   - if n is positive the requests are handled in separate goroutines but at
+//     most n request handlers are allowed to run at the same time: when n
+//     handlers are running the read loop waits for one of them to return
+//     before reading the next message.
+//
+// In the concurrent modes a request handler may safely wait for the response
+// of a request sent to the other side of the connection (if n is positive,
+// as long as no other request arrives while all the n handlers are busy).
+// Notifications are always dispatched synchronously, in the same order they
+// are received.
+// This method must be called before Run.
+func (c *Connection) SetRequestsConcurrency(n int) {
+	c.requestsConcurrency = n
+	c.requestsSemaphore = nil
+	if n > 0 {
+		c.requestsSemaphore = make(chan struct{}, n)
+	}
+}
+
+// Run reads and dispatches the incoming messages until the connection is
+// closed or the input stream fails.
 func (c *Connection) Run() {
-	in := textproto.NewReader(c.in)
-	for {
-		start := time.Now()
+	c.RunWithContext(context.Background())
+}
 
-		head, err := in.ReadMIMEHeader()
-		if err != nil {
-			c.errorHandler(err)
+// RunWithContext is like Run but also closes the connection as soon as the
+// given context is done.
+func (c *Connection) RunWithContext(ctx context.Context) {
+	go func() {
+		select {
+		case <-ctx.Done():
 			c.Close()
-			return
+		case <-c.closed:
 		}
+	}()
 
-		httpHeader := http.Header(head)
-		l := httpHeader.Get("Content-Length")
-		dataLen, err := strconv.Atoi(l)
-		if err != nil {
-			c.errorHandler(err)
-			c.Close()
-			return
-		}
+	for {
+		start := time.Now()
 
-		jsonData := make([]byte, dataLen)
-		if n, err := io.ReadFull(in.R, jsonData); err != nil {
-			c.errorHandler(err)
-			c.Close()
+		jsonData, err := c.framer.ReadMessage()
+		if err != nil {
+			c.closeWithError(err)
 			return
-		} else if n != dataLen {
-			c.errorHandler(fmt.Errorf("expected %d bytes but %d have been read", dataLen, n))
 		}
 
 		elapsed := time.Since(start)
@@ -116,61 +207,174 @@ func (c *Connection) Run() {
 		c.logger.LogIncomingDataDelay(elapsed)
 		c.loggerMutex.Unlock()
 
+		if c.IsClosed() {
+			return
+		}
+		c.dispatchMutex.Lock()
 		c.handleIncomingData(jsonData)
+		c.dispatchMutex.Unlock()
 	}
 }
 
 func (c *Connection) handleIncomingData(jsonData []byte) {
+	if isBatch(jsonData) {
+		c.handleIncomingBatch(jsonData)
+		return
+	}
+
+	msg, msgErr := decodeMessage(jsonData)
+	if msgErr != nil {
+		// The message can not be processed, the peer is notified with an error
+		// response with a null ID as required by the JSON-RPC specification.
+		c.errorHandler(fmt.Errorf("invalid request: %s", string(jsonData)))
+		c.sendResponse(&ResponseMessage{JSONRPC: "2.0", Error: msgErr})
+		return
+	}
+	switch msg := msg.(type) {
+	case *RequestMessage:
+		c.handleIncomingRequest(context.Background(), msg, func(resp *ResponseMessage) {
+			c.sendResponse(resp)
+			c.responsesSent(1)
+		})
+	case *NotificationMessage:
+		c.handleIncomingNotification(msg)
+	case *ResponseMessage:
+		c.handleIncomingResponse(msg)
+	}
+}
+
+// decodeMessage decodes a single JSON-RPC message into a *RequestMessage, a
+// *NotificationMessage or a *ResponseMessage. If the message is not valid
+// the error to send back to the peer is returned.
+func decodeMessage(jsonData []byte) (any, *ResponseError) {
 	var req RequestMessage
 	var notif NotificationMessage
 	var resp ResponseMessage
 	if err := json.Unmarshal(jsonData, &req); err == nil {
-		c.handleIncomingRequest(&req)
-	} else if err := json.Unmarshal(jsonData, &notif); err == nil {
-		c.handleIncomingNotification(&notif)
+		return &req, nil
+	} else if err := json.Unmarshal(jsonData, &notif); err == nil && !hasID(jsonData) {
+		return &notif, nil
 	} else if err := json.Unmarshal(jsonData, &resp); err == nil {
-		c.handleIncomingResponse(&resp)
-	} else {
-		c.errorHandler(fmt.Errorf("invalid request: %s", string(jsonData)))
-		c.Close()
+		return &resp, nil
 	}
+	if !json.Valid(jsonData) {
+		return nil, &ResponseError{Code: ErrorCodesParseError, Message: "parse error"}
+	}
+	return nil, &ResponseError{Code: ErrorCodesInvalidRequest, Message: "invalid request"}
 }
 
-func (c *Connection) handleIncomingRequest(req *RequestMessage) {
-	id := string(req.ID)
-	ctx, cancel := context.WithCancel(context.Background())
+// sendResponse sends a response (or a batch of responses) and closes the
+// connection if the output stream fails.
+func (c *Connection) sendResponse(resp any) {
+	if sendErr := c.send(resp); sendErr != nil && !c.IsClosed() {
+		c.closeWithError(fmt.Errorf("error sending response: %s", sendErr))
+	}
+}
 
+// responsesSent decreases the number of pending responses by n.
+func (c *Connection) responsesSent(n int) {
 	c.activeInRequestsMutex.Lock()
-	c.activeInRequests[id] = &inRequest{
+	c.pendingResponses -= n
+	if c.pendingResponses == 0 && c.responsesFlushed != nil {
+		close(c.responsesFlushed)
+		c.responsesFlushed = nil
+	}
+	c.activeInRequestsMutex.Unlock()
+}
+
+// hasID returns true if the message has an "id" field, of any type.
+func hasID(jsonData []byte) bool {
+	var msg struct {
+		ID json.RawMessage `json:"id"`
+	}
+	return json.Unmarshal(jsonData, &msg) == nil && msg.ID != nil
+}
+
+// handleIncomingRequest dispatches the request to the RequestHandler, the
+// response is passed to reply as soon as available. The context of the
+// request is derived from parent.
+func (c *Connection) handleIncomingRequest(parent context.Context, req *RequestMessage, reply func(*ResponseMessage)) {
+	id := req.ID
+	ctx, cancel := context.WithCancel(context.WithValue(parent, requestIDContextKey{}, id))
+
+	inReq := &inRequest{
 		cancel: cancel,
 	}
+	c.activeInRequestsMutex.Lock()
+	c.activeInRequests[id] = inReq
+	c.pendingResponses++
 	c.activeInRequestsMutex.Unlock()
 
 	c.loggerMutex.Lock()
 	logger := c.logger.LogIncomingRequest(id, req.Method, req.Params)
 	c.loggerMutex.Unlock()
 
-	c.requestHandler(ctx, logger, req.Method, req.Params, func(result json.RawMessage, resultErr *ResponseError) {
+	var responded atomic.Bool
+	respCallback := func(result json.RawMessage, resultErr *ResponseError) {
+		if responded.Swap(true) {
+			c.errorHandler(fmt.Errorf("request %s (%s) has already been answered", id, req.Method))
+			return
+		}
+
+		cancel()
 		c.activeInRequestsMutex.Lock()
-		c.activeInRequests[id].cancel()
-		delete(c.activeInRequests, id)
+		if c.activeInRequests[id] == inReq {
+			delete(c.activeInRequests, id)
+		}
 		c.activeInRequestsMutex.Unlock()
 
 		c.loggerMutex.Lock()
 		c.logger.LogOutgoingResponse(id, req.Method, result, resultErr)
 		c.loggerMutex.Unlock()
 
-		resp := &ResponseMessage{
+		// A response must have either a result or an error, but not both.
+		if resultErr != nil {
+			result = nil
+		} else if result == nil {
+			result = NullResult
+		}
+		reply(&ResponseMessage{
 			JSONRPC: "2.0",
 			ID:      req.ID,
 			Result:  result,
 			Error:   resultErr,
+		})
+	}
+
+	dispatch := func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panicErr := &HandlerPanicError{Method: req.Method, Value: r, Stack: debug.Stack()}
+				c.errorHandler(panicErr)
+				if !responded.Load() {
+					respCallback(nil, panicErr.ResponseError())
+				}
+			}
+		}()
+		c.requestHandler(ctx, logger, req.Method, req.Params, respCallback)
+	}
+
+	switch {
+	case c.requestsConcurrency == 0:
+		dispatch()
+	case c.requestsConcurrency < 0:
+		go dispatch()
+	default:
+		// The slot is taken in the read loop, so that at most n goroutines
+		// are started and the requests are dispatched in order: the next
+		// message is not read until a slot is free.
+		select {
+		case c.requestsSemaphore <- struct{}{}:
+		case <-ctx.Done():
+			// The connection has been closed while waiting
+			respCallback(nil, &ResponseError{Code: ErrorCodesRequestCancelled, Message: "request cancelled"})
+			return
 		}
-		if sendErr := c.send(resp); sendErr != nil {
-			c.errorHandler(fmt.Errorf("error sending response: %s", sendErr))
-			c.Close()
-		}
-	})
+		go func() {
+			defer func() { <-c.requestsSemaphore }()
+			dispatch()
+		}()
+	}
 }
 
 func (c *Connection) handleIncomingNotification(notif *NotificationMessage) {
@@ -189,14 +393,68 @@ func (c *Connection) handleIncomingNotification(notif *NotificationMessage) {
 	logger := c.logger.LogIncomingNotification(notif.Method, notif.Params)
 	c.loggerMutex.Unlock()
 
+	defer func() {
+		if r := recover(); r != nil {
+			c.errorHandler(&HandlerPanicError{Method: notif.Method, Value: r, Stack: debug.Stack()})
+		}
+	}()
 	c.notificationHandler(logger, notif.Method, notif.Params)
 }
 
+// DispatchLocalRequest dispatches a request to the RequestHandler as if it
+// had been received from the peer, and returns the response instead of
+// sending it to the peer. The request goes through the same path of the
+// incoming requests: it's tracked until answered (see WaitPendingResponses),
+// the panics of the handler are recovered, the configured requests
+// concurrency is honored and it's serialized with the dispatch of the
+// messages read from the peer. The context of the request is derived from
+// ctx. It must not be called from a handler running synchronously in the
+// read loop.
+func (c *Connection) DispatchLocalRequest(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, *ResponseError, error) {
+	if c.IsClosed() {
+		return nil, nil, &ConnectionClosedError{}
+	}
+	req := &RequestMessage{
+		JSONRPC: "2.0",
+		ID:      NewStringRequestID(fmt.Sprintf("$/local/%d", atomic.AddUint64(&c.lastLocalRequestsIdx, 1))),
+		Method:  method,
+		Params:  params,
+	}
+	respChan := make(chan *ResponseMessage, 1)
+	c.dispatchMutex.Lock()
+	c.handleIncomingRequest(ctx, req, func(resp *ResponseMessage) {
+		c.responsesSent(1)
+		respChan <- resp
+	})
+	c.dispatchMutex.Unlock()
+
+	select {
+	case resp := <-respChan:
+		return resp.Result, resp.Error, nil
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+// DispatchLocalNotification dispatches a notification to the
+// NotificationHandler as if it had been received from the peer, serialized
+// with the dispatch of the messages read from the peer. It must not be called
+// from a handler running synchronously in the read loop.
+func (c *Connection) DispatchLocalNotification(method string, params json.RawMessage) {
+	c.dispatchMutex.Lock()
+	defer c.dispatchMutex.Unlock()
+	c.handleIncomingNotification(&NotificationMessage{JSONRPC: "2.0", Method: method, Params: params})
+}
+
 func (c *Connection) handleIncomingResponse(resp *ResponseMessage) {
-	var id string
-	if err := json.Unmarshal(resp.ID, &id); err != nil {
-		c.errorHandler(fmt.Errorf("invalid ID in request response '%v': %w", resp.ID, err))
-		c.Close()
+	id := resp.ID
+	if id.IsNull() {
+		// The peer could not process one of our messages
+		if resp.Error != nil {
+			c.errorHandler(fmt.Errorf("error response from peer: %w", resp.Error.AsError()))
+		} else {
+			c.errorHandler(fmt.Errorf("unexpected response with null ID"))
+		}
 		return
 	}
 
@@ -208,8 +466,7 @@ func (c *Connection) handleIncomingResponse(resp *ResponseMessage) {
 	c.activeOutRequestsMutex.Unlock()
 
 	if !ok {
-		c.errorHandler(fmt.Errorf("invalid ID in request response '%s': double answer or request not sent", id))
-		c.Close()
+		c.closeWithError(fmt.Errorf("invalid ID in request response '%s': double answer or request not sent", id))
 		return
 	}
 
@@ -219,11 +476,11 @@ func (c *Connection) handleIncomingResponse(resp *ResponseMessage) {
 	}
 }
 
-func (c *Connection) cancelIncomingRequest(id json.RawMessage) {
+func (c *Connection) cancelIncomingRequest(id RequestID) {
 	c.activeInRequestsMutex.Lock()
-	if req, ok := c.activeInRequests[string(id)]; ok {
+	if req, ok := c.activeInRequests[id]; ok {
 		c.loggerMutex.Lock()
-		c.logger.LogIncomingCancelRequest(string(id))
+		c.logger.LogIncomingCancelRequest(id)
 		c.loggerMutex.Unlock()
 
 		req.cancel()
@@ -231,19 +488,87 @@ func (c *Connection) cancelIncomingRequest(id json.RawMessage) {
 	c.activeInRequestsMutex.Unlock()
 }
 
+// Close closes the connection: the read loop is stopped, the context of all
+// the incoming requests still in progress is canceled and all the pending
+// outgoing requests fail with a ConnectionClosedError. The Framer is closed
+// if it implements io.Closer (the framers provided by this package close the
+// underlying input and output streams if they implement io.Closer).
+// To gracefully terminate a connection call WaitPendingResponses before Close.
 func (c *Connection) Close() {
+	c.closeWithCause(nil)
 }
 
-func (c *Connection) SendRequest(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, *ResponseError, error) {
-	id := fmt.Sprintf("%d", atomic.AddUint64(&c.lastOutRequestsIndex, 1))
-	encodedID, err := json.Marshal(id)
-	if err != nil {
-		// should never happen...
-		panic("internal error creating RequestMessage")
+// IsClosed returns true if the connection has been closed.
+func (c *Connection) IsClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// closeWithError reports the error to the error handler and closes the
+// connection, the error is not reported if the connection is already closed.
+func (c *Connection) closeWithError(err error) {
+	if c.IsClosed() {
+		return
+	}
+	c.errorHandler(err)
+	c.closeWithCause(err)
+}
+
+func (c *Connection) closeWithCause(cause error) {
+	c.closeOnce.Do(func() {
+		c.activeOutRequestsMutex.Lock()
+		close(c.closed)
+		for id, req := range c.activeOutRequests {
+			req.resultChan <- &outResponse{err: &ConnectionClosedError{Cause: cause}}
+			delete(c.activeOutRequests, id)
+		}
+		c.activeOutRequestsMutex.Unlock()
+
+		c.activeInRequestsMutex.Lock()
+		for _, req := range c.activeInRequests {
+			req.cancel()
+		}
+		c.activeInRequestsMutex.Unlock()
+
+		if closer, ok := c.framer.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	})
+}
+
+// WaitPendingResponses waits until all the incoming requests received so far
+// have been answered and the responses have been written to the output stream,
+// or until the given context is done.
+func (c *Connection) WaitPendingResponses(ctx context.Context) error {
+	for {
+		c.activeInRequestsMutex.Lock()
+		if c.pendingResponses == 0 {
+			c.activeInRequestsMutex.Unlock()
+			return nil
+		}
+		if c.responsesFlushed == nil {
+			c.responsesFlushed = make(chan struct{})
+		}
+		flushed := c.responsesFlushed
+		c.activeInRequestsMutex.Unlock()
+
+		select {
+		case <-flushed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
+}
+
+func (c *Connection) SendRequest(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, *ResponseError, error) {
+	id := NewIntRequestID(int64(atomic.AddUint64(&c.lastOutRequestsIndex, 1)))
 	req := RequestMessage{
 		JSONRPC: "2.0",
-		ID:      encodedID,
+		ID:      id,
 		Method:  method,
 		Params:  params,
 	}
@@ -254,18 +579,39 @@ func (c *Connection) SendRequest(ctx context.Context, method string, params json
 
 	resultChan := make(chan *outResponse, 1)
 	c.activeOutRequestsMutex.Lock()
-	err = c.send(req)
-	if err == nil {
-		c.activeOutRequests[id] = &outRequest{
-			resultChan: resultChan,
-			method:     method,
-		}
+	if c.IsClosed() {
+		c.activeOutRequestsMutex.Unlock()
+		return nil, nil, &ConnectionClosedError{}
+	}
+	c.activeOutRequests[id] = &outRequest{
+		resultChan: resultChan,
+		method:     method,
 	}
 	c.activeOutRequestsMutex.Unlock()
-	if err != nil {
+
+	// The request is written without holding the lock: if the peer stops
+	// reading, Close must be able to close the framer to unblock the write.
+	if err := c.send(req); err != nil {
+		c.removeOutRequests(id)
 		return nil, nil, fmt.Errorf("sending request: %w", err)
 	}
 
+	return c.waitResponse(ctx, id, method, resultChan)
+}
+
+// removeOutRequests removes the outgoing requests with the given ids.
+func (c *Connection) removeOutRequests(ids ...RequestID) {
+	c.activeOutRequestsMutex.Lock()
+	for _, id := range ids {
+		delete(c.activeOutRequests, id)
+	}
+	c.activeOutRequestsMutex.Unlock()
+}
+
+// waitResponse waits the response to the outgoing request with the given id,
+// a cancel request is sent to the peer if the context is done before the
+// response is received.
+func (c *Connection) waitResponse(ctx context.Context, id RequestID, method string, resultChan <-chan *outResponse) (json.RawMessage, *ResponseError, error) {
 	// Wait the response or send cancel request if requested from context
 	var result *outResponse
 	select {
@@ -277,9 +623,8 @@ func (c *Connection) SendRequest(ctx context.Context, method string, params json
 		_, active := c.activeOutRequests[id]
 		c.activeOutRequestsMutex.Unlock()
 		if active {
-			if notif, err := json.Marshal(CancelParams{ID: encodedID}); err != nil {
-				// should never happen
-				panic("internal error: failed json encoding")
+			if notif, err := json.Marshal(CancelParams{ID: id}); err != nil {
+				c.errorHandler(fmt.Errorf("encoding cancel request for %s: %w", method, err))
 			} else {
 				c.loggerMutex.Lock()
 				c.logger.LogOutgoingCancelRequest(id)
@@ -292,6 +637,9 @@ func (c *Connection) SendRequest(ctx context.Context, method string, params json
 		// After cancelation wait for result...
 		result = <-resultChan
 	}
+	if result.err != nil {
+		return nil, nil, result.err
+	}
 
 	c.loggerMutex.Lock()
 	c.logger.LogIncomingResponse(id, method, result.reqResult, result.reqError)
@@ -301,6 +649,10 @@ func (c *Connection) SendRequest(ctx context.Context, method string, params json
 }
 
 func (c *Connection) SendNotification(method string, params json.RawMessage) error {
+	if c.IsClosed() {
+		return &ConnectionClosedError{}
+	}
+
 	c.loggerMutex.Lock()
 	c.logger.LogOutgoingNotification(method, params)
 	c.loggerMutex.Unlock()
@@ -325,16 +677,9 @@ func (c *Connection) send(data interface{}) error {
 	start := time.Now()
 	c.outMutex.Lock()
 	defer c.outMutex.Unlock()
-	if _, err := fmt.Fprintf(c.out, "Content-Length: %d\r\n\r\n", len(buff)); err != nil {
+	if err := c.framer.WriteMessage(buff); err != nil {
 		return err
 	}
-	for len(buff) > 0 {
-		n, err := c.out.Write(buff)
-		if err != nil {
-			return err
-		}
-		buff = buff[n:]
-	}
 	elapsed := time.Since(start)
 	c.loggerMutex.Lock()
 	c.logger.LogOutgoingDataDelay(elapsed)
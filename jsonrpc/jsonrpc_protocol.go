@@ -7,12 +7,97 @@
 package jsonrpc
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"strconv"
 
 	"go.bug.st/json"
 )
 
-type RequestID json.RawMessage
+// RequestID is the ID of a request, it may be an integer or a string.
+// The zero value is the null ID, that is used only in error responses to
+// messages that could not be parsed. A RequestID keeps the type of the
+// value so that it is always echoed back as received, and it can be used
+// as a map key.
+type RequestID struct {
+	value any // nil, int64 or string
+}
+
+// NewIntRequestID creates a new integer RequestID
+func NewIntRequestID(id int64) RequestID {
+	return RequestID{value: id}
+}
+
+// NewStringRequestID creates a new string RequestID
+func NewStringRequestID(id string) RequestID {
+	return RequestID{value: id}
+}
+
+// IsNull returns true if the RequestID is the null ID
+func (id RequestID) IsNull() bool {
+	return id.value == nil
+}
+
+// Int returns the integer value of the RequestID and true, or 0 and false if
+// the RequestID is not an integer.
+func (id RequestID) Int() (int64, bool) {
+	n, ok := id.value.(int64)
+	return n, ok
+}
+
+// Str returns the string value of the RequestID and true, or "" and false if
+// the RequestID is not a string.
+func (id RequestID) Str() (string, bool) {
+	s, ok := id.value.(string)
+	return s, ok
+}
+
+func (id RequestID) String() string {
+	switch v := id.value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case string:
+		return v
+	default:
+		return "null"
+	}
+}
+
+func (id RequestID) MarshalJSON() ([]byte, error) {
+	return json.Marshal(id.value)
+}
+
+func (id *RequestID) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		*id = RequestID{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*id = NewStringRequestID(s)
+		return nil
+	}
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid request ID %s: must be an integer or a string", data)
+	}
+	*id = NewIntRequestID(n)
+	return nil
+}
+
+type requestIDContextKey struct{}
+
+// RequestIDFromContext returns the ID of the request being handled, the
+// context must be the one passed to a RequestHandler.
+func RequestIDFromContext(ctx context.Context) (RequestID, bool) {
+	id, ok := ctx.Value(requestIDContextKey{}).(RequestID)
+	return id, ok
+}
 
 // A RequestMessage to describe a request between the client and the server. Every
 // processed request must send a response back to the sender of the request.
@@ -21,7 +106,7 @@ type RequestMessage struct {
 	JSONRPC string `json:"jsonrpc,required"`
 
 	// The request id.
-	ID json.RawMessage `json:"id,required"`
+	ID RequestID `json:"id,required"`
 
 	// The method to be invoked.
 	Method string `json:"method,required"`
@@ -39,7 +124,7 @@ type ResponseMessage struct {
 	JSONRPC string `json:"jsonrpc,required"`
 
 	// The request id.
-	ID json.RawMessage `json:"id,required"`
+	ID RequestID `json:"id,required"`
 
 	// The result of a request. This member is REQUIRED on success.
 	// This member MUST NOT exist if there was an error invoking the method.
@@ -137,7 +222,7 @@ type NotificationMessage struct {
 // to ErrorCodesRequestCancelled.
 type CancelParams struct {
 	// ID The request id to cancel.
-	ID json.RawMessage `json:"id,required"`
+	ID RequestID `json:"id,required"`
 }
 
 // ProgressParams The base protocol offers also support to report progress in a generic fashion.
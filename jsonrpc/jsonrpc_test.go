@@ -10,8 +10,11 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"sync"
 	"testing"
@@ -61,7 +64,7 @@ func TestRPCConnection(t *testing.T) {
 	simulatedResult := `
 {
 	"jsonrpc": "2.0",
-	"id": "1",
+	"id": 1,
 	"result": {"fakedata":999}
 }`
 	testdata := fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(notifData), notifData)
@@ -131,7 +134,7 @@ func TestRPCConnection(t *testing.T) {
 			"REQ method=tocancel params=[123 10 9 125]\n"+
 			"", resp)
 
-	require.Equal(t, "Content-Length: 72\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":\"1\",\"method\":\"helloworld\",\"params\":{\"Field\":true}}Content-Length: 64\r\n\r\n{\"jsonrpc\":\"2.0\",\"method\":\"$/cancelRequest\",\"params\":{\"id\":\"1\"}}Content-Length: 38\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":1,\"result\":null}Content-Length: 38\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":2,\"result\":null}Content-Length: 69\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":3,\"error\":{\"code\":1,\"message\":\"error message\"}}", output.String())
+	require.Equal(t, "Content-Length: 70\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":1,\"method\":\"helloworld\",\"params\":{\"Field\":true}}Content-Length: 62\r\n\r\n{\"jsonrpc\":\"2.0\",\"method\":\"$/cancelRequest\",\"params\":{\"id\":1}}Content-Length: 38\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":1,\"result\":null}Content-Length: 38\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":2,\"result\":null}Content-Length: 69\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":3,\"error\":{\"code\":1,\"message\":\"error message\"}}", output.String())
 	// fmt.Println(output.String())
 }
 
@@ -184,3 +187,558 @@ func TestUnmarshallingRequestVsResponse(t *testing.T) {
 	require.Error(json.Unmarshal(responseErrorData, &request))
 	require.NoError(json.Unmarshal(responseErrorData, &response))
 }
+
+func TestRequestsConcurrency(t *testing.T) {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	var server *Connection
+	server = NewConnection(serverIn, serverOut,
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+			// Send a request back to the client and wait for the answer
+			// before replying: this would deadlock in synchronous mode.
+			res, resErr, err := server.SendRequest(ctx, "reverse", params)
+			require.NoError(t, err)
+			require.Nil(t, resErr)
+			respCallback(res, nil)
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {},
+		func(e error) {},
+	)
+	server.SetRequestsConcurrency(-1)
+
+	client := NewConnection(clientIn, clientOut,
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+			require.Equal(t, "reverse", method)
+			respCallback(params, nil)
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {},
+		func(e error) {},
+	)
+	client.SetRequestsConcurrency(1)
+
+	go server.Run()
+	go client.Run()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		res, resErr, err := client.SendRequest(context.Background(), "forward", json.RawMessage(`{"value":42}`))
+		require.NoError(t, err)
+		require.Nil(t, resErr)
+		require.Equal(t, `{"value":42}`, string(res))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("request not answered")
+	}
+}
+
+func TestRequestsConcurrencyLimit(t *testing.T) {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	var running, maxRunning int
+	var runningMutex sync.Mutex
+	release := make(chan struct{})
+	server := NewConnection(serverIn, serverOut,
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+			runningMutex.Lock()
+			running++
+			maxRunning = max(maxRunning, running)
+			runningMutex.Unlock()
+			<-release
+			runningMutex.Lock()
+			running--
+			runningMutex.Unlock()
+			respCallback(params, nil)
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {},
+		func(e error) {},
+	)
+	server.SetRequestsConcurrency(2)
+	client := NewConnection(clientIn, clientOut, nil, nil, func(e error) {})
+	go server.Run()
+	go client.Run()
+	defer client.Close()
+	defer server.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, resErr, err := client.SendRequest(context.Background(), "wait", json.RawMessage(fmt.Sprint(i)))
+			require.NoError(t, err)
+			require.Nil(t, resErr)
+			require.Equal(t, fmt.Sprint(i), string(res))
+		}()
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	// The read loop waits for a free slot: only the request following the
+	// running ones has been read
+	server.activeInRequestsMutex.Lock()
+	pending := len(server.activeInRequests)
+	server.activeInRequestsMutex.Unlock()
+	require.Equal(t, 3, pending)
+
+	close(release)
+	wg.Wait()
+	require.Equal(t, 2, maxRunning)
+}
+
+func TestConnectionClose(t *testing.T) {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	releaseHandler := make(chan struct{})
+	handlerCanceled := make(chan struct{})
+	server := NewConnection(serverIn, serverOut,
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+			go func() {
+				select {
+				case <-releaseHandler:
+					respCallback(NullResult, nil)
+				case <-ctx.Done():
+					close(handlerCanceled)
+					respCallback(nil, &ResponseError{Code: ErrorCodesRequestCancelled})
+				}
+			}()
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {},
+		func(e error) {},
+	)
+	client := NewConnection(clientIn, clientOut,
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+			// never answer
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {},
+		func(e error) {},
+	)
+	serverCtx, serverCancel := context.WithCancel(context.Background())
+	serverDone := make(chan struct{})
+	go func() {
+		server.RunWithContext(serverCtx)
+		close(serverDone)
+	}()
+	go client.Run()
+
+	// WaitPendingResponses returns only after the pending request is answered
+	go func() {
+		_, _, _ = client.SendRequest(context.Background(), "first", NullResult)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	require.True(t, errors.Is(server.WaitPendingResponses(ctx), context.DeadlineExceeded))
+	cancel()
+	close(releaseHandler)
+	require.NoError(t, server.WaitPendingResponses(context.Background()))
+
+	// On Close the in-flight handlers are canceled and the pending requests fail
+	releaseHandler = make(chan struct{})
+	go func() {
+		_, _, _ = client.SendRequest(context.Background(), "second", NullResult)
+	}()
+	pendingResult := make(chan error)
+	go func() {
+		_, _, err := server.SendRequest(context.Background(), "to-client", NullResult)
+		pendingResult <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	serverCancel()
+
+	select {
+	case err := <-pendingResult:
+		var closedErr *ConnectionClosedError
+		require.True(t, errors.As(err, &closedErr))
+	case <-time.After(time.Second):
+		t.Fatal("pending request not failed")
+	}
+	select {
+	case <-handlerCanceled:
+	case <-time.After(time.Second):
+		t.Fatal("in-flight handler not canceled")
+	}
+	select {
+	case <-serverDone:
+	case <-time.After(time.Second):
+		t.Fatal("read loop not stopped")
+	}
+	require.True(t, server.IsClosed())
+	_, _, err := server.SendRequest(context.Background(), "after-close", NullResult)
+	require.Error(t, err)
+	require.Error(t, server.SendNotification("after-close", NullResult))
+}
+
+func TestConnectionCloseStuckPeer(t *testing.T) {
+	// The peer never reads: the writes block until the connection is closed
+	in, _ := io.Pipe()
+	_, out := io.Pipe()
+	conn := NewConnection(in, out,
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {},
+		func(e error) {},
+	)
+	go conn.Run()
+
+	requestErr := make(chan error, 2)
+	go func() {
+		_, _, err := conn.SendRequest(context.Background(), "stuck", NullResult)
+		requestErr <- err
+	}()
+	go func() {
+		_, err := conn.SendBatch(context.Background(), []BatchMessage{{Method: "stuck", Params: NullResult}})
+		requestErr <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		conn.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Close blocked by a pending write")
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-requestErr:
+			require.Error(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("pending request not failed")
+		}
+	}
+	conn.activeOutRequestsMutex.Lock()
+	require.Empty(t, conn.activeOutRequests)
+	conn.activeOutRequestsMutex.Unlock()
+}
+
+func TestMalformedMessages(t *testing.T) {
+	invalidJSON := `{"jsonrpc": "2.0", "method": "foo", "params": [`
+	invalidRequest := `{"jsonrpc": "2.0", "foo": "bar"}`
+	validRequest := `{"jsonrpc": "2.0", "id": 1, "method": "hello"}`
+	testdata := fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(invalidJSON), invalidJSON)
+	testdata += fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(invalidRequest), invalidRequest)
+	testdata += fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(validRequest), validRequest)
+
+	output := &bytes.Buffer{}
+	errs := 0
+	conn := NewConnection(
+		strings.NewReader(testdata),
+		output,
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+			respCallback(NullResult, nil)
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {},
+		func(e error) {
+			if e != io.EOF {
+				errs++
+			}
+		},
+	)
+	conn.Run()
+	require.Equal(t, 2, errs)
+	require.Equal(t, ""+
+		"Content-Length: 75\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":null,\"error\":{\"code\":-32700,\"message\":\"parse error\"}}"+
+		"Content-Length: 79\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":null,\"error\":{\"code\":-32600,\"message\":\"invalid request\"}}"+
+		"Content-Length: 38\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":1,\"result\":null}", output.String())
+}
+
+func TestHandlerPanicRecovery(t *testing.T) {
+	testdata := ""
+	for _, msg := range []string{
+		`{"jsonrpc": "2.0", "method": "boom"}`,
+		`{"jsonrpc": "2.0", "id": 1, "method": "boom"}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "hello"}`,
+	} {
+		testdata += fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+
+	output := &bytes.Buffer{}
+	panics := []string{}
+	conn := NewConnection(
+		strings.NewReader(testdata),
+		output,
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+			if method == "boom" {
+				panic("request exploded")
+			}
+			respCallback(NullResult, nil)
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {
+			panic("notification exploded")
+		},
+		func(e error) {
+			var panicErr *HandlerPanicError
+			if errors.As(e, &panicErr) {
+				panics = append(panics, fmt.Sprint(panicErr.Value))
+			}
+		},
+	)
+	conn.Run()
+	require.Equal(t, []string{"notification exploded", "request exploded"}, panics)
+
+	out := output.String()
+	require.Contains(t, out, `{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"panic in boom handler: request exploded","data":{"panic":"request exploded","stack":"`)
+	require.Contains(t, out, `{"jsonrpc":"2.0","id":2,"result":null}`)
+}
+
+func TestDispatchLocalMessages(t *testing.T) {
+	output := &bytes.Buffer{}
+	panics := []string{}
+	notifications := []string{}
+	conn := NewConnection(
+		strings.NewReader(""),
+		output,
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+			if method == "boom" {
+				panic("request exploded")
+			}
+			respCallback(params, nil)
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {
+			notifications = append(notifications, method)
+		},
+		func(e error) {
+			var panicErr *HandlerPanicError
+			if errors.As(e, &panicErr) {
+				panics = append(panics, fmt.Sprint(panicErr.Value))
+			}
+		},
+	)
+
+	res, resErr, err := conn.DispatchLocalRequest(context.Background(), "echo", json.RawMessage(`{"a":1}`))
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	require.Equal(t, `{"a":1}`, string(res))
+
+	_, resErr, err = conn.DispatchLocalRequest(context.Background(), "boom", nil)
+	require.NoError(t, err)
+	require.Equal(t, ErrorCodesInternalError, resErr.Code)
+	require.Equal(t, []string{"request exploded"}, panics)
+
+	conn.DispatchLocalNotification("hello", nil)
+	require.Equal(t, []string{"hello"}, notifications)
+
+	// The responses are not sent to the peer and nothing is left pending
+	require.Empty(t, output.String())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, conn.WaitPendingResponses(ctx))
+
+	conn.Close()
+	_, _, err = conn.DispatchLocalRequest(context.Background(), "echo", nil)
+	require.IsType(t, &ConnectionClosedError{}, err)
+}
+
+func TestRequestID(t *testing.T) {
+	for _, data := range []string{`1`, `"1"`, `"abc"`, `null`, `-42`} {
+		var id RequestID
+		require.NoError(t, json.Unmarshal([]byte(data), &id))
+		encoded, err := json.Marshal(id)
+		require.NoError(t, err)
+		require.Equal(t, data, string(encoded))
+	}
+	var id RequestID
+	require.Error(t, json.Unmarshal([]byte(`1.5`), &id))
+	require.Error(t, json.Unmarshal([]byte(`{}`), &id))
+	require.NotEqual(t, NewIntRequestID(1), NewStringRequestID("1"))
+
+	// IDs are echoed back with the same type and are available to the handler
+	testdata := ""
+	for _, msg := range []string{
+		`{"jsonrpc": "2.0", "id": "abc", "method": "hello"}`,
+		`{"jsonrpc": "2.0", "id": 7, "method": "hello"}`,
+		`{"jsonrpc": "2.0", "id": "7", "method": "hello"}`,
+	} {
+		testdata += fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+	output := &bytes.Buffer{}
+	ids := []RequestID{}
+	conn := NewConnection(
+		strings.NewReader(testdata),
+		output,
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+			id, ok := RequestIDFromContext(ctx)
+			require.True(t, ok)
+			ids = append(ids, id)
+			respCallback(NullResult, nil)
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {},
+		func(e error) {},
+	)
+	conn.Run()
+	require.Equal(t, []RequestID{NewStringRequestID("abc"), NewIntRequestID(7), NewStringRequestID("7")}, ids)
+	require.Equal(t, ""+
+		"Content-Length: 42\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":\"abc\",\"result\":null}"+
+		"Content-Length: 38\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":7,\"result\":null}"+
+		"Content-Length: 40\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":\"7\",\"result\":null}", output.String())
+}
+
+func TestIncomingBatch(t *testing.T) {
+	testdata := ""
+	for _, msg := range []string{
+		`[
+			{"jsonrpc": "2.0", "id": 1, "method": "hello"},
+			{"jsonrpc": "2.0", "method": "notify"},
+			{"foo": "bar"},
+			{"jsonrpc": "2.0", "id": "2", "method": "hello"}
+		]`,
+		`[{"jsonrpc": "2.0", "method": "notify"}, {"jsonrpc": "2.0", "method": "notify"}]`,
+		`[]`,
+	} {
+		testdata += fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+	output := &bytes.Buffer{}
+	notifications := 0
+	conn := NewConnection(
+		strings.NewReader(testdata),
+		output,
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+			respCallback(json.RawMessage(`"world"`), nil)
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {
+			notifications++
+		},
+		func(e error) {},
+	)
+	conn.Run()
+	require.Equal(t, 3, notifications)
+	require.Equal(t, ""+
+		"Content-Length: 167\r\n\r\n["+
+		"{\"jsonrpc\":\"2.0\",\"id\":null,\"error\":{\"code\":-32600,\"message\":\"invalid request\"}},"+
+		"{\"jsonrpc\":\"2.0\",\"id\":1,\"result\":\"world\"},"+
+		"{\"jsonrpc\":\"2.0\",\"id\":\"2\",\"result\":\"world\"}]"+
+		"Content-Length: 79\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":null,\"error\":{\"code\":-32600,\"message\":\"invalid request\"}}", output.String())
+}
+
+func TestSendBatch(t *testing.T) {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	notified := make(chan string, 1)
+	server := NewConnection(serverIn, serverOut,
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+			if method == "fail" {
+				respCallback(nil, &ResponseError{Code: ErrorCodesInvalidParams, Message: "failed"})
+				return
+			}
+			respCallback(params, nil)
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {
+			notified <- method
+		},
+		func(e error) {},
+	)
+	server.SetRequestsConcurrency(-1)
+	client := NewConnection(clientIn, clientOut, nil, nil, func(e error) {})
+	go server.Run()
+	go client.Run()
+
+	res, err := client.SendBatch(context.Background(), []BatchMessage{
+		{Method: "echo", Params: json.RawMessage(`1`)},
+		{Method: "notify", Notification: true},
+		{Method: "fail"},
+		{Method: "echo", Params: json.RawMessage(`"two"`)},
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 4)
+	require.Equal(t, "1", string(res[0].Result))
+	require.Nil(t, res[0].Error)
+	require.Equal(t, BatchResponse{}, res[1])
+	require.Equal(t, ErrorCodesInvalidParams, res[2].Error.Code)
+	require.Equal(t, `"two"`, string(res[3].Result))
+	require.Equal(t, "notify", <-notified)
+
+	_, err = client.SendBatch(context.Background(), nil)
+	require.Error(t, err)
+}
+
+func TestNewlineFramer(t *testing.T) {
+	input := "{\"jsonrpc\": \"2.0\", \"id\": 1, \"method\": \"hello\"}\n" +
+		"\r\n" +
+		"{\"jsonrpc\": \"2.0\", \"method\": \"notify\"}\r\n" +
+		"{\"jsonrpc\": \"2.0\", \"id\": 2, \"method\": \"hello\"}"
+	output := &bytes.Buffer{}
+	notifications := 0
+	conn := NewConnectionWithFramer(
+		NewNewlineFramer(strings.NewReader(input), output),
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+			respCallback(NullResult, nil)
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {
+			notifications++
+		},
+		func(e error) {},
+	)
+	conn.Run()
+	require.Equal(t, 1, notifications)
+	require.Equal(t, ""+
+		"{\"jsonrpc\":\"2.0\",\"id\":1,\"result\":null}\n"+
+		"{\"jsonrpc\":\"2.0\",\"id\":2,\"result\":null}\n", output.String())
+
+	framer := NewNewlineFramer(strings.NewReader(""), &bytes.Buffer{})
+	require.Error(t, framer.WriteMessage([]byte("{\n}")))
+}
+
+func TestHeaderFramer(t *testing.T) {
+	output := &bytes.Buffer{}
+	framer := NewHeaderFramer(strings.NewReader("Content-Length: 2\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n{}Content-Length: 5\r\n\r\n{}"), output)
+	msg, err := framer.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, "{}", string(msg))
+	_, err = framer.ReadMessage()
+	require.Error(t, err)
+
+	require.NoError(t, framer.WriteMessage([]byte(`{"a":1}`)))
+	require.Equal(t, "Content-Length: 7\r\n\r\n{\"a\":1}", output.String())
+}
+
+func TestWebSocketFramer(t *testing.T) {
+	serverErrors := make(chan error, 1)
+	httpServer := httptest.NewServer(WebSocketHandler(nil, func(r *http.Request, framer Framer) {
+		conn := NewConnectionWithFramer(framer,
+			func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+				require.Equal(t, "echo", method)
+				respCallback(params, nil)
+			},
+			func(logger FunctionLogger, method string, params json.RawMessage) {},
+			func(e error) { serverErrors <- e },
+		)
+		conn.Run()
+	}))
+	defer httpServer.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+	framer, err := DialWebSocket(context.Background(), wsURL, nil)
+	require.NoError(t, err)
+	client := NewConnectionWithFramer(framer,
+		func(ctx context.Context, logger FunctionLogger, method string, params json.RawMessage, respCallback func(result json.RawMessage, err *ResponseError)) {
+		},
+		func(logger FunctionLogger, method string, params json.RawMessage) {},
+		func(e error) {},
+	)
+	go client.Run()
+
+	res, resErr, err := client.SendRequest(context.Background(), "echo", json.RawMessage(`{"value":42}`))
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	require.Equal(t, `{"value":42}`, string(res))
+
+	// A graceful close is seen as an EOF by the other side
+	client.Close()
+	select {
+	case err := <-serverErrors:
+		require.Equal(t, io.EOF, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server connection not closed")
+	}
+
+	_, err = DialWebSocket(context.Background(), httpServer.URL+"/not-ws", nil)
+	require.Error(t, err)
+}
@@ -13,14 +13,14 @@ import (
 )
 
 type Logger interface {
-	LogOutgoingRequest(id string, method string, params json.RawMessage)
-	LogIncomingRequest(id string, method string, params json.RawMessage) FunctionLogger
-	LogOutgoingResponse(id string, method string, resp json.RawMessage, respErr *ResponseError)
-	LogIncomingResponse(id string, method string, resp json.RawMessage, respErr *ResponseError)
+	LogOutgoingRequest(id RequestID, method string, params json.RawMessage)
+	LogIncomingRequest(id RequestID, method string, params json.RawMessage) FunctionLogger
+	LogOutgoingResponse(id RequestID, method string, resp json.RawMessage, respErr *ResponseError)
+	LogIncomingResponse(id RequestID, method string, resp json.RawMessage, respErr *ResponseError)
 	LogOutgoingNotification(method string, params json.RawMessage)
 	LogIncomingNotification(method string, params json.RawMessage) FunctionLogger
-	LogIncomingCancelRequest(id string)
-	LogOutgoingCancelRequest(id string)
+	LogIncomingCancelRequest(id RequestID)
+	LogOutgoingCancelRequest(id RequestID)
 	LogIncomingDataDelay(time.Duration)
 	LogOutgoingDataDelay(time.Duration)
 }
@@ -31,17 +31,17 @@ type FunctionLogger interface {
 
 type NullLogger struct{}
 
-func (NullLogger) LogOutgoingRequest(id string, method string, params json.RawMessage) {
+func (NullLogger) LogOutgoingRequest(id RequestID, method string, params json.RawMessage) {
 }
 
-func (NullLogger) LogIncomingRequest(id string, method string, params json.RawMessage) FunctionLogger {
+func (NullLogger) LogIncomingRequest(id RequestID, method string, params json.RawMessage) FunctionLogger {
 	return &NullFunctionLogger{}
 }
 
-func (NullLogger) LogOutgoingResponse(id string, method string, resp json.RawMessage, respErr *ResponseError) {
+func (NullLogger) LogOutgoingResponse(id RequestID, method string, resp json.RawMessage, respErr *ResponseError) {
 }
 
-func (NullLogger) LogIncomingResponse(id string, method string, resp json.RawMessage, respErr *ResponseError) {
+func (NullLogger) LogIncomingResponse(id RequestID, method string, resp json.RawMessage, respErr *ResponseError) {
 }
 
 func (NullLogger) LogOutgoingNotification(method string, params json.RawMessage) {
@@ -51,9 +51,9 @@ func (NullLogger) LogIncomingNotification(method string, params json.RawMessage)
 	return &NullFunctionLogger{}
 }
 
-func (NullLogger) LogIncomingCancelRequest(id string) {}
+func (NullLogger) LogIncomingCancelRequest(id RequestID) {}
 
-func (NullLogger) LogOutgoingCancelRequest(id string) {}
+func (NullLogger) LogOutgoingCancelRequest(id RequestID) {}
 
 type NullFunctionLogger struct{}
 
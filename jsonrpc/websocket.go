@@ -0,0 +1,98 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package jsonrpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// webSocketFramer adapts a WebSocket connection to a Framer: each JSON-RPC
+// message is exchanged in a single WebSocket text frame, without any header.
+type webSocketFramer struct {
+	conn      *websocket.Conn
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewWebSocketFramer returns a Framer that sends and receives the messages
+// through the given WebSocket connection, one message per text frame.
+// Closing the Framer closes the WebSocket connection.
+func NewWebSocketFramer(conn *websocket.Conn) Framer {
+	return &webSocketFramer{conn: conn}
+}
+
+func (f *webSocketFramer) ReadMessage() ([]byte, error) {
+	for {
+		msgType, data, err := f.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+		if msgType == websocket.TextMessage || msgType == websocket.BinaryMessage {
+			return data, nil
+		}
+	}
+}
+
+func (f *webSocketFramer) WriteMessage(data []byte) error {
+	return f.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (f *webSocketFramer) Close() error {
+	f.closeOnce.Do(func() {
+		// Try to close the connection gracefully, the error is ignored because
+		// the peer may have already gone away.
+		msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+		_ = f.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+		f.closeErr = f.conn.Close()
+	})
+	return f.closeErr
+}
+
+// WebSocketHandler returns an http.Handler that upgrades the incoming HTTP
+// requests to WebSocket and calls serve with a Framer for each connection.
+// serve is expected to run a Connection (or an lsp.Server) on the Framer and
+// return when it's done; the WebSocket is closed when serve returns.
+// If upgrader is nil a default websocket.Upgrader is used, that rejects
+// cross-origin requests.
+func WebSocketHandler(upgrader *websocket.Upgrader, serve func(r *http.Request, framer Framer)) http.Handler {
+	if upgrader == nil {
+		upgrader = &websocket.Upgrader{}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// In case of failure Upgrade already replies with an HTTP error
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		framer := NewWebSocketFramer(conn)
+		defer framer.(io.Closer).Close()
+		serve(r, framer)
+	})
+}
+
+// DialWebSocket connects to the WebSocket server at the given URL (ws:// or
+// wss://) and returns a Framer for the established connection.
+func DialWebSocket(ctx context.Context, url string, header http.Header) (Framer, error) {
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, url, header)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("%w (HTTP status: %s)", err, resp.Status)
+		}
+		return nil, err
+	}
+	return NewWebSocketFramer(conn), nil
+}
@@ -0,0 +1,178 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"fmt"
+	"sync"
+
+	"go.bug.st/lsp/jsonrpc"
+)
+
+// LifecycleState is the state of an LSP session, as defined by the
+// initialize/shutdown/exit lifecycle of the protocol.
+type LifecycleState int
+
+const (
+	// LifecycleUninitialized is the state before the initialize request.
+	LifecycleUninitialized LifecycleState = iota
+	// LifecycleInitializing is the state after the initialize request, until
+	// the initialized notification.
+	LifecycleInitializing
+	// LifecycleRunning is the state after the initialized notification.
+	LifecycleRunning
+	// LifecycleShuttingDown is the state after the shutdown request.
+	LifecycleShuttingDown
+	// LifecycleExited is the state after the exit notification.
+	LifecycleExited
+)
+
+func (s LifecycleState) String() string {
+	switch s {
+	case LifecycleUninitialized:
+		return "uninitialized"
+	case LifecycleInitializing:
+		return "initializing"
+	case LifecycleRunning:
+		return "running"
+	case LifecycleShuttingDown:
+		return "shutting down"
+	case LifecycleExited:
+		return "exited"
+	default:
+		return fmt.Sprintf("LifecycleState(%d)", int(s))
+	}
+}
+
+// LifecycleError is returned when a message is not allowed in the current
+// lifecycle state.
+type LifecycleError struct {
+	State  LifecycleState
+	Method string
+}
+
+func (e *LifecycleError) Error() string {
+	return fmt.Sprintf("%s not allowed while %s", e.Method, e.State)
+}
+
+// ResponseError returns the error response for a request rejected because of
+// the lifecycle state.
+func (e *LifecycleError) ResponseError() *jsonrpc.ResponseError {
+	code := jsonrpc.ErrorCodesInvalidRequest
+	if e.State == LifecycleUninitialized || (e.State == LifecycleInitializing && e.Method != "initialize") {
+		code = jsonrpc.ErrorCodesServerNotInitialized
+	}
+	return &jsonrpc.ResponseError{Code: code, Message: e.Error()}
+}
+
+// lifecycle tracks the lifecycle state of a session. The state is always
+// tracked; the rules of the specification are enforced only if guard is set.
+type lifecycle struct {
+	mutex sync.Mutex
+	state LifecycleState
+	guard bool
+	// initializeDone is set once the initialize request has been answered
+	// successfully.
+	initializeDone bool
+	// cleanExit is set if the exit notification has been received after
+	// the shutdown request.
+	cleanExit bool
+}
+
+func (l *lifecycle) setGuard(guard bool) {
+	l.mutex.Lock()
+	l.guard = guard
+	l.mutex.Unlock()
+}
+
+func (l *lifecycle) current() LifecycleState {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.state
+}
+
+// exitedCleanly returns true if the session has been terminated by the exit
+// notification after the shutdown request.
+func (l *lifecycle) exitedCleanly() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.state == LifecycleExited && l.cleanExit
+}
+
+// request checks if the request with the given method is allowed and updates
+// the state accordingly. A LifecycleError is returned if the request must be
+// rejected.
+func (l *lifecycle) request(method string) *LifecycleError {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	allowed := false
+	switch l.state {
+	case LifecycleUninitialized:
+		allowed = method == "initialize"
+	case LifecycleInitializing:
+		allowed = method != "initialize" && l.initializeDone
+	case LifecycleRunning:
+		allowed = method != "initialize"
+	}
+	if !allowed && l.guard {
+		return &LifecycleError{State: l.state, Method: method}
+	}
+
+	switch {
+	case method == "initialize" && l.state == LifecycleUninitialized:
+		l.state = LifecycleInitializing
+	case method == "shutdown" && l.state < LifecycleShuttingDown:
+		l.state = LifecycleShuttingDown
+	}
+	return nil
+}
+
+// initializeResult must be called when the initialize request has been
+// answered. If the initialize request failed the state is reset.
+func (l *lifecycle) initializeResult(success bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.state != LifecycleInitializing {
+		return
+	}
+	if success {
+		l.initializeDone = true
+	} else {
+		l.state = LifecycleUninitialized
+	}
+}
+
+// notification checks if the notification with the given method is allowed
+// and updates the state accordingly. A LifecycleError is returned if the
+// notification must be dropped.
+func (l *lifecycle) notification(method string) *LifecycleError {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	allowed := false
+	switch {
+	case method == "exit":
+		allowed = true
+	case method == "initialized":
+		allowed = l.state == LifecycleInitializing && l.initializeDone
+	default:
+		allowed = l.state == LifecycleRunning
+	}
+	if !allowed && l.guard {
+		return &LifecycleError{State: l.state, Method: method}
+	}
+
+	switch {
+	case method == "exit":
+		l.cleanExit = l.state == LifecycleShuttingDown
+		l.state = LifecycleExited
+	case method == "initialized" && l.state == LifecycleInitializing:
+		l.state = LifecycleRunning
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.bug.st/lsp/jsonrpc"
+)
+
+// lifecycleHandler is a ClientMessagesHandler that implements only the
+// lifecycle messages.
+type lifecycleHandler struct {
+	UnimplementedClientMessagesHandler
+	calls []string
+}
+
+func (h *lifecycleHandler) Initialize(ctx context.Context, logger jsonrpc.FunctionLogger, params *InitializeParams) (*InitializeResult, *jsonrpc.ResponseError) {
+	h.calls = append(h.calls, "initialize")
+	return &InitializeResult{}, nil
+}
+
+func (h *lifecycleHandler) Initialized(logger jsonrpc.FunctionLogger, params *InitializedParams) {
+	h.calls = append(h.calls, "initialized")
+}
+
+func (h *lifecycleHandler) Shutdown(ctx context.Context, logger jsonrpc.FunctionLogger) *jsonrpc.ResponseError {
+	h.calls = append(h.calls, "shutdown")
+	return nil
+}
+
+func (h *lifecycleHandler) Exit(logger jsonrpc.FunctionLogger) {
+	h.calls = append(h.calls, "exit")
+}
+
+func TestServerLifecycleGuard(t *testing.T) {
+	input := encodeFrames(
+		`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"initialize","params":{"processId":null,"rootUri":null,"capabilities":{}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"initialize","params":{"processId":null,"rootUri":null,"capabilities":{}}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","id":5,"method":"unknown/method"}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+	output := &bytes.Buffer{}
+	handler := &lifecycleHandler{}
+	serv := NewServer(strings.NewReader(input), output, handler)
+	serv.SetLifecycleGuard(true)
+	require.Equal(t, LifecycleUninitialized, serv.LifecycleState())
+	serv.Run()
+
+	require.Equal(t, []string{"initialize", "initialized", "shutdown", "exit"}, handler.calls)
+	require.Equal(t, LifecycleExited, serv.LifecycleState())
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":1,"error":{"code":-32002,"message":"shutdown not allowed while uninitialized"}}`)
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":2,"result":{"capabilities":`)
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":3,"error":{"code":-32600,"message":"initialize not allowed while initializing"}}`)
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":4,"result":null}`)
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":5,"error":{"code":-32600,"message":"unknown/method not allowed while shutting down"}}`)
+}
+
+func TestServerLifecycleWithoutGuard(t *testing.T) {
+	input := encodeFrames(
+		`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	)
+	handler := &lifecycleHandler{}
+	serv := NewServer(strings.NewReader(input), &bytes.Buffer{}, handler)
+	serv.Run()
+	require.Equal(t, []string{"shutdown", "exit"}, handler.calls)
+	require.Equal(t, LifecycleExited, serv.LifecycleState())
+}
+
+func TestClientLifecycleGuard(t *testing.T) {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	handler := &lifecycleHandler{}
+	serv := NewServer(serverIn, serverOut, handler)
+	go serv.Run()
+
+	client := NewClient(clientIn, clientOut, nil)
+	client.SetLifecycleGuard(true)
+	go client.Run()
+	defer client.Close()
+
+	var lifecycleErr *LifecycleError
+	_, err := client.Shutdown(context.Background())
+	require.True(t, errors.As(err, &lifecycleErr))
+	require.Equal(t, LifecycleUninitialized, lifecycleErr.State)
+	require.True(t, errors.As(client.Initialized(&InitializedParams{}), &lifecycleErr))
+
+	_, respErr, err := client.Initialize(context.Background(), &InitializeParams{})
+	require.NoError(t, err)
+	require.Nil(t, respErr)
+	require.Equal(t, LifecycleInitializing, client.LifecycleState())
+	require.NoError(t, client.Initialized(&InitializedParams{}))
+	require.Equal(t, LifecycleRunning, client.LifecycleState())
+
+	respErr, err = client.Shutdown(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, respErr)
+	require.Equal(t, LifecycleShuttingDown, client.LifecycleState())
+	require.True(t, errors.As(client.Initialized(&InitializedParams{}), &lifecycleErr))
+	require.NoError(t, client.Exit())
+	require.Equal(t, LifecycleExited, client.LifecycleState())
+}
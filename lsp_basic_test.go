@@ -125,3 +125,19 @@ func TestDecodingProgressReports(t *testing.T) {
 		require.Equal(t, "bye", end.(WorkDoneProgressEnd).Message)
 	}
 }
+
+func TestNegotiatePositionEncoding(t *testing.T) {
+	var caps ClientCapabilities
+	require.Equal(t, PositionEncodingKindUTF16, NegotiatePositionEncoding(&caps, PositionEncodingKindUTF8))
+
+	err := json.Unmarshal([]byte(`{"general":{"positionEncodings":["utf-32","utf-8","utf-16"]}}`), &caps)
+	require.NoError(t, err)
+	require.Equal(t, PositionEncodingKindUTF8, NegotiatePositionEncoding(&caps, PositionEncodingKindUTF8, PositionEncodingKindUTF16))
+	require.Equal(t, PositionEncodingKindUTF32, NegotiatePositionEncoding(&caps, PositionEncodingKindUTF8, PositionEncodingKindUTF32))
+	require.Equal(t, PositionEncodingKindUTF16, NegotiatePositionEncoding(&caps))
+	require.Equal(t, PositionEncodingKindUTF16, NegotiatePositionEncoding(nil, PositionEncodingKindUTF8))
+
+	data, err := json.Marshal(&ServerCapabilities{PositionEncoding: PositionEncodingKindUTF8})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"positionEncoding":"utf-8"}`, string(data))
+}
@@ -59,6 +59,21 @@ type ClientCapabilities struct {
 		// @since 3.16.0
 		CodeLens *CodeLensWorkspaceClientCapabilities `json:"codeLens,omitempty"`
 
+		// Client workspace capabilities specific to inline values.
+		//
+		// @since 3.17.0
+		InlineValue *InlineValueWorkspaceClientCapabilities `json:"inlineValue,omitempty"`
+
+		// Client workspace capabilities specific to inlay hints.
+		//
+		// @since 3.17.0
+		InlayHint *InlayHintWorkspaceClientCapabilities `json:"inlayHint,omitempty"`
+
+		// Client workspace capabilities specific to diagnostics.
+		//
+		// @since 3.17.0
+		Diagnostics *DiagnosticWorkspaceClientCapabilities `json:"diagnostics,omitempty"`
+
 		// The client has support for file requests/notifications.
 		//
 		// @since 3.16.0
@@ -90,6 +105,11 @@ type ClientCapabilities struct {
 	// Text document specific client capabilities.
 	TextDocument *TextDocumentClientCapabilities `json:"textDocument,omitempty"`
 
+	// Capabilities specific to the notebook document support.
+	//
+	// @since 3.17.0
+	NotebookDocument *NotebookDocumentClientCapabilities `json:"notebookDocument,omitempty"`
+
 	// Window specific client capabilities.
 	Window *struct {
 
@@ -141,6 +161,26 @@ type ClientCapabilities struct {
 		//
 		// @since 3.16.0
 		Markdown *MarkdownClientCapabilities `json:"markdown,omitempty"`
+
+		// The position encodings supported by the client. Client and server
+		// have to agree on the same position encoding to ensure that offsets
+		// (e.g. character position in a line) are interpreted the same on both
+		// side.
+		//
+		// To keep the protocol backwards compatible the following applies: if
+		// the value 'utf-16' is missing from the array of position encodings
+		// servers can assume that the client supports UTF-16. UTF-16 is
+		// therefore a mandatory encoding.
+		//
+		// If omitted it defaults to ['utf-16'].
+		//
+		// Implementation considerations: since the conversion from one encoding
+		// into another requires the content of the file / line the conversion
+		// is best done where the file is read which is usually on the server
+		// side.
+		//
+		// @since 3.17.0
+		PositionEncodings []PositionEncodingKind `json:"positionEncodings,omitempty"`
 	} `json:"general,omitempty"`
 
 	// Experimental client capabilities.
@@ -374,6 +414,51 @@ type CodeLensWorkspaceClientCapabilities struct {
 	RefreshSupport bool `json:"refreshSupport,omitempty"`
 }
 
+// InlineValueWorkspaceClientCapabilities Client workspace capabilities
+// specific to inline values.
+//
+// @since 3.17.0
+type InlineValueWorkspaceClientCapabilities struct {
+	// Whether the client implementation supports a refresh request sent from
+	// the server to the client.
+	//
+	// Note that this event is global and will force the client to refresh all
+	// inline values currently shown. It should be used with absolute care and
+	// is useful for situation where a server for example detect a project wide
+	// change that requires such a calculation.
+	RefreshSupport bool `json:"refreshSupport,omitempty"`
+}
+
+// InlayHintWorkspaceClientCapabilities Client workspace capabilities
+// specific to inlay hints.
+//
+// @since 3.17.0
+type InlayHintWorkspaceClientCapabilities struct {
+	// Whether the client implementation supports a refresh request sent from
+	// the server to the client.
+	//
+	// Note that this event is global and will force the client to refresh all
+	// inlay hints currently shown. It should be used with absolute care and
+	// is useful for situation where a server for example detects a project wide
+	// change that requires such a calculation.
+	RefreshSupport bool `json:"refreshSupport,omitempty"`
+}
+
+// DiagnosticWorkspaceClientCapabilities Workspace client capabilities
+// specific to diagnostic pull requests.
+//
+// @since 3.17.0
+type DiagnosticWorkspaceClientCapabilities struct {
+	// Whether the client implementation supports a refresh request sent from
+	// the server to the client.
+	//
+	// Note that this event is global and will force the client to refresh all
+	// pulled diagnostics currently shown. It should be used with absolute care
+	// and is useful for situation where a server for example detects a project
+	// wide change that requires such a calculation.
+	RefreshSupport bool `json:"refreshSupport,omitempty"`
+}
+
 type TextDocumentClientCapabilities struct {
 	Synchronization *TextDocumentSyncClientCapabilities `json:"synchronization,omitempty"`
 
@@ -469,10 +554,53 @@ type TextDocumentClientCapabilities struct {
 	// @since 3.16.0
 	SemanticTokens *SemanticTokensClientCapabilities `json:"semanticTokens,omitempty"`
 
+	// Capabilities specific to the various type hierarchy requests.
+	//
+	// @since 3.17.0
+	TypeHierarchy *TypeHierarchyClientCapabilities `json:"typeHierarchy,omitempty"`
+
 	// Capabilities specific to the `textDocument/moniker` request.
 	//
 	// @since 3.16.0
 	Moniker *MonikerClientCapabilities `json:"moniker,omitempty"`
+
+	// Capabilities specific to the `textDocument/inlineValue` request.
+	//
+	// @since 3.17.0
+	InlineValue *InlineValueClientCapabilities `json:"inlineValue,omitempty"`
+
+	// Capabilities specific to the `textDocument/inlayHint` request.
+	//
+	// @since 3.17.0
+	InlayHint *InlayHintClientCapabilities `json:"inlayHint,omitempty"`
+
+	// Capabilities specific to the diagnostic pull model.
+	//
+	// @since 3.17.0
+	Diagnostic *DiagnosticClientCapabilities `json:"diagnostic,omitempty"`
+}
+
+// NotebookDocumentClientCapabilities Capabilities specific to the notebook
+// document support.
+//
+// @since 3.17.0
+type NotebookDocumentClientCapabilities struct {
+	// Capabilities specific to notebook document synchronization
+	Synchronization NotebookDocumentSyncClientCapabilities `json:"synchronization,required"`
+}
+
+// NotebookDocumentSyncClientCapabilities Notebook specific client capabilities.
+//
+// @since 3.17.0
+type NotebookDocumentSyncClientCapabilities struct {
+	// Whether implementation supports dynamic registration. If this is
+	// set to `true` the client supports the new
+	// `(NotebookDocumentSyncRegistrationOptions & NotebookDocumentSyncOptions)`
+	// return value for the corresponding server capability as well.
+	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+
+	// The client supports sending execution summary data per cell.
+	ExecutionSummarySupport bool `json:"executionSummarySupport,omitempty"`
 }
 
 type TextDocumentSyncClientCapabilities struct {
@@ -1080,6 +1208,14 @@ type CallHierarchyClientCapabilities struct {
 	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 }
 
+type TypeHierarchyClientCapabilities struct {
+	// Whether implementation supports dynamic registration. If this is set to
+	// `true` the client supports the new `(TextDocumentRegistrationOptions &
+	// StaticRegistrationOptions)` return value for the corresponding server
+	// capability as well.
+	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+}
+
 type SemanticTokensClientCapabilities struct {
 	// Whether implementation supports dynamic registration. If this is set to
 	// `true` the client supports the new `(TextDocumentRegistrationOptions &
@@ -1134,6 +1270,47 @@ type MonikerClientCapabilities struct {
 	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
 }
 
+// InlineValueClientCapabilities Client capabilities specific to inline
+// values.
+//
+// @since 3.17.0
+type InlineValueClientCapabilities struct {
+	// Whether implementation supports dynamic registration for inline
+	// value providers.
+	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+}
+
+// InlayHintClientCapabilities Inlay hint client capabilities.
+//
+// @since 3.17.0
+type InlayHintClientCapabilities struct {
+	// Whether inlay hints support dynamic registration.
+	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+
+	// Indicates which properties a client can resolve lazily on an inlay
+	// hint.
+	ResolveSupport *struct {
+		// The properties that a client can resolve lazily.
+		Properties []string `json:"properties,required"`
+	} `json:"resolveSupport,omitempty"`
+}
+
+// DiagnosticClientCapabilities Client capabilities specific to diagnostic
+// pull requests.
+//
+// @since 3.17.0
+type DiagnosticClientCapabilities struct {
+	// Whether implementation supports dynamic registration. If this is set to
+	// `true` the client supports the new
+	// `(TextDocumentRegistrationOptions & StaticRegistrationOptions)`
+	// return value for the corresponding server capability as well.
+	DynamicRegistration bool `json:"dynamicRegistration,omitempty"`
+
+	// Whether the clients supports related documents for document diagnostic
+	// pulls.
+	RelatedDocumentSupport bool `json:"relatedDocumentSupport,omitempty"`
+}
+
 // CodeActionKind The kind of a code action.
 //
 // Kinds are a hierarchical list of identifiers separated by `.`,
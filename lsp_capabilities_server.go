@@ -13,12 +13,28 @@ import (
 )
 
 type ServerCapabilities struct {
+	// The position encoding the server picked from the encodings offered
+	// by the client via the client capability `general.positionEncodings`.
+	//
+	// If the client didn't provide any position encodings the only valid
+	// value that a server can return is 'utf-16'.
+	//
+	// If omitted it defaults to 'utf-16'.
+	//
+	// @since 3.17.0
+	PositionEncoding PositionEncodingKind `json:"positionEncoding,omitempty"`
+
 	// Defines how text documents are synced. Is either a detailed structure
 	// defining each notification or for backwards compatibility the
 	// TextDocumentSyncKind number. If omitted it defaults to
 	// `TextDocumentSyncKind.None`.
 	TextDocumentSync *TextDocumentSyncOptions `json:"textDocumentSync,omitempty"`
 
+	// Defines how notebook documents are synced.
+	//
+	// @since 3.17.0
+	NotebookDocumentSync *NotebookDocumentSyncOptions `json:"notebookDocumentSync,omitempty"`
+
 	// The server provides completion support.
 	CompletionProvider *CompletionOptions `json:"completionProvider,omitempty"`
 
@@ -108,6 +124,11 @@ type ServerCapabilities struct {
 	// @since 3.16.0
 	CallHierarchyProvider *CallHierarchyOptions `json:"callHierarchyProvider,omitempty"`
 
+	// The server provides type hierarchy support.
+	//
+	// @since 3.17.0
+	TypeHierarchyProvider *TypeHierarchyOptions `json:"typeHierarchyProvider,omitempty"`
+
 	// The server provides semantic tokens support.
 	//
 	// @since 3.16.0
@@ -118,6 +139,21 @@ type ServerCapabilities struct {
 	// @since 3.16.0
 	MonikerProvider *MonikerOptions `json:"monikerProvider,omitempty"`
 
+	// The server provides inline values.
+	//
+	// @since 3.17.0
+	InlineValueProvider *InlineValueOptions `json:"inlineValueProvider,omitempty"`
+
+	// The server provides inlay hints.
+	//
+	// @since 3.17.0
+	InlayHintProvider *InlayHintOptions `json:"inlayHintProvider,omitempty"`
+
+	// The server has support for pull model diagnostics.
+	//
+	// @since 3.17.0
+	DiagnosticProvider *DiagnosticRegistrationOptions `json:"diagnosticProvider,omitempty"`
+
 	// The server provides workspace symbol support.
 	WorkspaceSymbolProvider *WorkspaceSymbolOptions `json:"workspaceSymbolProvider,omitempty"`
 
@@ -190,6 +226,85 @@ type TextDocumentSyncOptions struct {
 	Save *SaveOptions `json:"save,omitempty"`
 }
 
+// NotebookDocumentSyncOptions NotebookDocumentSyncOptions | NotebookDocumentSyncRegistrationOptions
+//
+// Options specific to a notebook plus its cells
+// to be synced to the server.
+//
+// If a selector provides a notebook document
+// filter but no cell selector all cells of a
+// matching notebook document will be synced.
+//
+// If a selector provides no notebook document
+// filter but only a cell selector all notebook
+// documents that contain at least one matching
+// cell will be synced.
+//
+// @since 3.17.0
+type NotebookDocumentSyncOptions struct {
+	*StaticRegistrationOptions
+
+	// The notebooks to be synced
+	NotebookSelector []NotebookSelector `json:"notebookSelector,required"`
+
+	// Whether save notification should be forwarded to
+	// the server. Will only be honored if mode === `notebook`.
+	Save bool `json:"save,omitempty"`
+}
+
+// NotebookSelector A selector of notebook documents and cells to be synced.
+//
+// @since 3.17.0
+type NotebookSelector struct {
+	// The notebook to be synced. If a string
+	// value is provided it matches against the
+	// notebook type. '*' matches every notebook.
+	Notebook *NotebookDocumentFilter `json:"notebook,omitempty"`
+
+	// The cells of the matching notebook to be synced.
+	Cells []NotebookCellSelector `json:"cells,omitempty"`
+}
+
+// NotebookCellSelector A selector of the cells of a notebook document.
+//
+// @since 3.17.0
+type NotebookCellSelector struct {
+	Language string `json:"language,required"`
+}
+
+// NotebookDocumentFilter A notebook document filter denotes a notebook document
+// by different properties.
+//
+// @since 3.17.0
+type NotebookDocumentFilter struct {
+	// The type of the enclosing notebook.
+	NotebookType string `json:"notebookType,omitempty"`
+
+	// A Uri [scheme](#Uri.scheme), like `file` or `untitled`.
+	Scheme string `json:"scheme,omitempty"`
+
+	// A glob pattern.
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// UnmarshalJSON string | NotebookDocumentFilter, a string matches against the
+// notebook type.
+func (f *NotebookDocumentFilter) UnmarshalJSON(data []byte) error {
+	notebookType := ""
+	if err := json.Unmarshal(data, &notebookType); err == nil {
+		*f = NotebookDocumentFilter{NotebookType: notebookType}
+		return nil
+	}
+
+	type __ NotebookDocumentFilter // avoid loops
+	var res __
+	if err := json.Unmarshal(data, &res); err == nil {
+		*f = NotebookDocumentFilter(res)
+		return nil
+	}
+	return fmt.Errorf("expected string or NotebookDocumentFilter")
+}
+
 type SaveOptions struct {
 	// The client is supposed to include the content on save.
 	IncludeText bool `json:"includeText,omitempty"`
@@ -771,6 +886,33 @@ func (s *CallHierarchyOptions) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("expected boolean or CallHierarchyOptions")
 }
 
+// TypeHierarchyOptions boolean | TypeHierarchyOptions | TypeHierarchyRegistrationOptions
+//
+// @since 3.17.0
+type TypeHierarchyOptions struct {
+	*WorkDoneProgressOptions
+	*TextDocumentRegistrationOptions
+	*StaticRegistrationOptions
+}
+
+func (s *TypeHierarchyOptions) UnmarshalJSON(data []byte) error {
+	save := false
+	if err := json.Unmarshal(data, &save); err == nil {
+		if save {
+			*s = TypeHierarchyOptions{}
+		}
+		return nil
+	}
+
+	type __ TypeHierarchyOptions // avoid loops
+	var res __
+	if err := json.Unmarshal(data, &res); err == nil {
+		*s = TypeHierarchyOptions(res)
+		return nil
+	}
+	return fmt.Errorf("expected boolean or TypeHierarchyOptions")
+}
+
 type SemanticTokensOptions struct {
 	*TextDocumentRegistrationOptions
 	*StaticRegistrationOptions
@@ -841,6 +983,93 @@ func (s *MonikerOptions) UnmarshalJSON(data []byte) error {
 	return fmt.Errorf("expected boolean or MonikerOptions")
 }
 
+// InlineValueOptions boolean | InlineValueOptions | InlineValueRegistrationOptions
+//
+// @since 3.17.0
+type InlineValueOptions struct {
+	*WorkDoneProgressOptions
+	*TextDocumentRegistrationOptions
+	*StaticRegistrationOptions
+}
+
+func (s *InlineValueOptions) UnmarshalJSON(data []byte) error {
+	save := false
+	if err := json.Unmarshal(data, &save); err == nil {
+		if save {
+			*s = InlineValueOptions{}
+		}
+		return nil
+	}
+
+	type __ InlineValueOptions // avoid loops
+	var res __
+	if err := json.Unmarshal(data, &res); err == nil {
+		*s = InlineValueOptions(res)
+		return nil
+	}
+	return fmt.Errorf("expected boolean or InlineValueOptions")
+}
+
+// InlayHintOptions boolean | InlayHintOptions | InlayHintRegistrationOptions
+//
+// @since 3.17.0
+type InlayHintOptions struct {
+	*WorkDoneProgressOptions
+	*TextDocumentRegistrationOptions
+	*StaticRegistrationOptions
+
+	// The server provides support to resolve additional
+	// information for an inlay hint item.
+	ResolveProvider bool `json:"resolveProvider,omitempty"`
+}
+
+func (s *InlayHintOptions) UnmarshalJSON(data []byte) error {
+	save := false
+	if err := json.Unmarshal(data, &save); err == nil {
+		if save {
+			*s = InlayHintOptions{}
+		}
+		return nil
+	}
+
+	type __ InlayHintOptions // avoid loops
+	var res __
+	if err := json.Unmarshal(data, &res); err == nil {
+		*s = InlayHintOptions(res)
+		return nil
+	}
+	return fmt.Errorf("expected boolean or InlayHintOptions")
+}
+
+// DiagnosticOptions Diagnostic options.
+//
+// @since 3.17.0
+type DiagnosticOptions struct {
+	*WorkDoneProgressOptions
+
+	// An optional identifier under which the diagnostics are
+	// managed by the client.
+	Identifier string `json:"identifier,omitempty"`
+
+	// Whether the language has inter file dependencies meaning that
+	// editing code in one file can result in a different diagnostic
+	// set in another file. Inter file dependencies are common for
+	// most programming languages and typically uncommon for linters.
+	InterFileDependencies bool `json:"interFileDependencies"`
+
+	// The server provides support for workspace diagnostics as well.
+	WorkspaceDiagnostics bool `json:"workspaceDiagnostics"`
+}
+
+// DiagnosticRegistrationOptions Diagnostic registration options.
+//
+// @since 3.17.0
+type DiagnosticRegistrationOptions struct {
+	*TextDocumentRegistrationOptions
+	DiagnosticOptions
+	*StaticRegistrationOptions
+}
+
 type WorkspaceSymbolOptions struct {
 	*WorkDoneProgressOptions
 }
@@ -153,6 +153,7 @@ func TestInitializeResult(t *testing.T) {
 	var sc InitializeResult
 	err := json.Unmarshal(clangd11ServerCapabilities, &sc)
 	require.NoError(t, err)
+	require.NotNil(t, sc.Capabilities.TypeHierarchyProvider)
 	fmt.Println(sc.Capabilities.SemanticTokensProvider)
 
 	_, err = json.MarshalIndent(&InitializeResult{
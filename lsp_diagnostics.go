@@ -133,3 +133,325 @@ func (dt DiagnosticTag) String() string {
 		return fmt.Sprintf("Unknown (%d)", int(dt))
 	}
 }
+
+// DocumentDiagnosticParams Parameters of the document diagnostic request.
+//
+// @since 3.17.0
+type DocumentDiagnosticParams struct {
+	*WorkDoneProgressParams
+	*PartialResultParams
+
+	// The text document.
+	TextDocument TextDocumentIdentifier `json:"textDocument,required"`
+
+	// The additional identifier provided during registration.
+	Identifier string `json:"identifier,omitempty"`
+
+	// The result id of a previous response if provided.
+	PreviousResultID string `json:"previousResultId,omitempty"`
+}
+
+// DocumentDiagnosticReportKind The document diagnostic report kinds.
+//
+// @since 3.17.0
+type DocumentDiagnosticReportKind string
+
+// DocumentDiagnosticReportKindFull A diagnostic report with a full
+// set of problems.
+const DocumentDiagnosticReportKindFull DocumentDiagnosticReportKind = "full"
+
+// DocumentDiagnosticReportKindUnchanged A report indicating that the last
+// returned report is still accurate.
+const DocumentDiagnosticReportKindUnchanged DocumentDiagnosticReportKind = "unchanged"
+
+// FullDocumentDiagnosticReport A diagnostic report with a full set of problems.
+//
+// @since 3.17.0
+type FullDocumentDiagnosticReport struct {
+	// Kind string `json:"kind,required"` /* automatically set to 'full' */
+
+	// An optional result id. If provided it will be sent on the next
+	// diagnostic request for the same document.
+	ResultID string `json:"resultId,omitempty"`
+
+	// The actual items.
+	Items []Diagnostic `json:"items,required"`
+}
+
+func (r *FullDocumentDiagnosticReport) UnmarshalJSON(data []byte) error {
+	type __ FullDocumentDiagnosticReport
+	var res __
+	if err := unmarshalDiagnosticReport(data, DocumentDiagnosticReportKindFull, &res); err != nil {
+		return err
+	}
+	*r = FullDocumentDiagnosticReport(res)
+	return nil
+}
+
+func (r FullDocumentDiagnosticReport) MarshalJSON() ([]byte, error) {
+	type __ FullDocumentDiagnosticReport
+	if r.Items == nil {
+		r.Items = []Diagnostic{}
+	}
+	return marshalDiagnosticReport(DocumentDiagnosticReportKindFull, __(r))
+}
+
+// UnchangedDocumentDiagnosticReport A diagnostic report indicating that the
+// last returned report is still accurate.
+//
+// @since 3.17.0
+type UnchangedDocumentDiagnosticReport struct {
+	// Kind string `json:"kind,required"` /* automatically set to 'unchanged' */
+
+	// A result id which will be sent on the next diagnostic request for the
+	// same document.
+	ResultID string `json:"resultId,required"`
+}
+
+func (r *UnchangedDocumentDiagnosticReport) UnmarshalJSON(data []byte) error {
+	type __ UnchangedDocumentDiagnosticReport
+	var res __
+	if err := unmarshalDiagnosticReport(data, DocumentDiagnosticReportKindUnchanged, &res); err != nil {
+		return err
+	}
+	*r = UnchangedDocumentDiagnosticReport(res)
+	return nil
+}
+
+func (r UnchangedDocumentDiagnosticReport) MarshalJSON() ([]byte, error) {
+	type __ UnchangedDocumentDiagnosticReport
+	return marshalDiagnosticReport(DocumentDiagnosticReportKindUnchanged, __(r))
+}
+
+// RelatedFullDocumentDiagnosticReport A full diagnostic report with a set of
+// related documents.
+//
+// @since 3.17.0
+type RelatedFullDocumentDiagnosticReport struct {
+	// Kind string `json:"kind,required"` /* automatically set to 'full' */
+
+	// An optional result id. If provided it will be sent on the next
+	// diagnostic request for the same document.
+	ResultID string `json:"resultId,omitempty"`
+
+	// The actual items.
+	Items []Diagnostic `json:"items,required"`
+
+	// Diagnostics of related documents. This information is useful in
+	// programming languages where code in a file A can generate diagnostics in
+	// a file B which A depends on. An example of such a language is C/C++
+	// where marco definitions in a file a.cpp and result in errors in a
+	// header file b.hpp.
+	RelatedDocuments map[DocumentURI]FullOrUnchangedDocumentDiagnosticReport `json:"relatedDocuments,omitempty"`
+}
+
+func (r *RelatedFullDocumentDiagnosticReport) UnmarshalJSON(data []byte) error {
+	type __ RelatedFullDocumentDiagnosticReport
+	var res __
+	if err := unmarshalDiagnosticReport(data, DocumentDiagnosticReportKindFull, &res); err != nil {
+		return err
+	}
+	*r = RelatedFullDocumentDiagnosticReport(res)
+	return nil
+}
+
+func (r RelatedFullDocumentDiagnosticReport) MarshalJSON() ([]byte, error) {
+	type __ RelatedFullDocumentDiagnosticReport
+	if r.Items == nil {
+		r.Items = []Diagnostic{}
+	}
+	return marshalDiagnosticReport(DocumentDiagnosticReportKindFull, __(r))
+}
+
+// RelatedUnchangedDocumentDiagnosticReport An unchanged diagnostic report
+// with a set of related documents.
+//
+// @since 3.17.0
+type RelatedUnchangedDocumentDiagnosticReport struct {
+	// Kind string `json:"kind,required"` /* automatically set to 'unchanged' */
+
+	// A result id which will be sent on the next diagnostic request for the
+	// same document.
+	ResultID string `json:"resultId,required"`
+
+	// Diagnostics of related documents. This information is useful in
+	// programming languages where code in a file A can generate diagnostics in
+	// a file B which A depends on. An example of such a language is C/C++
+	// where marco definitions in a file a.cpp and result in errors in a
+	// header file b.hpp.
+	RelatedDocuments map[DocumentURI]FullOrUnchangedDocumentDiagnosticReport `json:"relatedDocuments,omitempty"`
+}
+
+func (r *RelatedUnchangedDocumentDiagnosticReport) UnmarshalJSON(data []byte) error {
+	type __ RelatedUnchangedDocumentDiagnosticReport
+	var res __
+	if err := unmarshalDiagnosticReport(data, DocumentDiagnosticReportKindUnchanged, &res); err != nil {
+		return err
+	}
+	*r = RelatedUnchangedDocumentDiagnosticReport(res)
+	return nil
+}
+
+func (r RelatedUnchangedDocumentDiagnosticReport) MarshalJSON() ([]byte, error) {
+	type __ RelatedUnchangedDocumentDiagnosticReport
+	return marshalDiagnosticReport(DocumentDiagnosticReportKindUnchanged, __(r))
+}
+
+// DocumentDiagnosticReportPartialResult A partial result for a document
+// diagnostic report.
+//
+// @since 3.17.0
+type DocumentDiagnosticReportPartialResult struct {
+	RelatedDocuments map[DocumentURI]FullOrUnchangedDocumentDiagnosticReport `json:"relatedDocuments,required"`
+}
+
+// DiagnosticServerCancellationData Cancellation data returned from a
+// diagnostic request.
+//
+// @since 3.17.0
+type DiagnosticServerCancellationData struct {
+	RetriggerRequest bool `json:"retriggerRequest,required"`
+}
+
+// WorkspaceDiagnosticParams Parameters of the workspace diagnostic request.
+//
+// @since 3.17.0
+type WorkspaceDiagnosticParams struct {
+	*WorkDoneProgressParams
+	*PartialResultParams
+
+	// The additional identifier provided during registration.
+	Identifier string `json:"identifier,omitempty"`
+
+	// The currently known diagnostic reports with their
+	// previous result ids.
+	PreviousResultIDs []PreviousResultID `json:"previousResultIds,required"`
+}
+
+// PreviousResultID A previous result id in a workspace pull request.
+//
+// @since 3.17.0
+type PreviousResultID struct {
+	// The URI for which the client knows a result id.
+	URI DocumentURI `json:"uri,required"`
+
+	// The value of the previous result id.
+	Value string `json:"value,required"`
+}
+
+// WorkspaceDiagnosticReport A workspace diagnostic report.
+//
+// @since 3.17.0
+type WorkspaceDiagnosticReport struct {
+	Items []WorkspaceDocumentDiagnosticReport `json:"items,required"`
+}
+
+// WorkspaceDiagnosticReportPartialResult A partial result for a workspace
+// diagnostic report.
+//
+// @since 3.17.0
+type WorkspaceDiagnosticReportPartialResult struct {
+	Items []WorkspaceDocumentDiagnosticReport `json:"items,required"`
+}
+
+// WorkspaceFullDocumentDiagnosticReport A full document diagnostic report
+// for a workspace diagnostic result.
+//
+// @since 3.17.0
+type WorkspaceFullDocumentDiagnosticReport struct {
+	// Kind string `json:"kind,required"` /* automatically set to 'full' */
+
+	// An optional result id. If provided it will be sent on the next
+	// diagnostic request for the same document.
+	ResultID string `json:"resultId,omitempty"`
+
+	// The actual items.
+	Items []Diagnostic `json:"items,required"`
+
+	// The URI for which diagnostic information is reported.
+	URI DocumentURI `json:"uri,required"`
+
+	// The version number for which the diagnostics are reported.
+	// If the document is not marked as open `null` can be provided.
+	Version *int `json:"version"`
+}
+
+func (r *WorkspaceFullDocumentDiagnosticReport) UnmarshalJSON(data []byte) error {
+	type __ WorkspaceFullDocumentDiagnosticReport
+	var res __
+	if err := unmarshalDiagnosticReport(data, DocumentDiagnosticReportKindFull, &res); err != nil {
+		return err
+	}
+	*r = WorkspaceFullDocumentDiagnosticReport(res)
+	return nil
+}
+
+func (r WorkspaceFullDocumentDiagnosticReport) MarshalJSON() ([]byte, error) {
+	type __ WorkspaceFullDocumentDiagnosticReport
+	if r.Items == nil {
+		r.Items = []Diagnostic{}
+	}
+	return marshalDiagnosticReport(DocumentDiagnosticReportKindFull, __(r))
+}
+
+// WorkspaceUnchangedDocumentDiagnosticReport An unchanged document diagnostic
+// report for a workspace diagnostic result.
+//
+// @since 3.17.0
+type WorkspaceUnchangedDocumentDiagnosticReport struct {
+	// Kind string `json:"kind,required"` /* automatically set to 'unchanged' */
+
+	// A result id which will be sent on the next diagnostic request for the
+	// same document.
+	ResultID string `json:"resultId,required"`
+
+	// The URI for which diagnostic information is reported.
+	URI DocumentURI `json:"uri,required"`
+
+	// The version number for which the diagnostics are reported.
+	// If the document is not marked as open `null` can be provided.
+	Version *int `json:"version"`
+}
+
+func (r *WorkspaceUnchangedDocumentDiagnosticReport) UnmarshalJSON(data []byte) error {
+	type __ WorkspaceUnchangedDocumentDiagnosticReport
+	var res __
+	if err := unmarshalDiagnosticReport(data, DocumentDiagnosticReportKindUnchanged, &res); err != nil {
+		return err
+	}
+	*r = WorkspaceUnchangedDocumentDiagnosticReport(res)
+	return nil
+}
+
+func (r WorkspaceUnchangedDocumentDiagnosticReport) MarshalJSON() ([]byte, error) {
+	type __ WorkspaceUnchangedDocumentDiagnosticReport
+	return marshalDiagnosticReport(DocumentDiagnosticReportKindUnchanged, __(r))
+}
+
+// unmarshalDiagnosticReport decodes data in res after checking that the kind
+// of the report is the expected one.
+func unmarshalDiagnosticReport(data []byte, kind DocumentDiagnosticReportKind, res interface{}) error {
+	var temp struct {
+		Kind DocumentDiagnosticReportKind `json:"kind,required"`
+	}
+	if err := json.Unmarshal(data, &temp); err != nil {
+		return err
+	}
+	if temp.Kind != kind {
+		return fmt.Errorf("invalid Kind field value '%s': must be '%s'", temp.Kind, kind)
+	}
+	return json.Unmarshal(data, res)
+}
+
+// marshalDiagnosticReport encodes report adding the kind field.
+func marshalDiagnosticReport(kind DocumentDiagnosticReportKind, report interface{}) ([]byte, error) {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	res := []byte(`{"kind":"` + string(kind) + `"`)
+	if len(data) > 2 {
+		res = append(res, ',')
+	}
+	return append(res, data[1:]...), nil
+}
@@ -1210,3 +1210,255 @@ type Moniker struct {
 	// The moniker kind if known.
 	Kind MonikerKind `json:"kind,omitempty"`
 }
+
+// InlayHintParams A parameter literal used in inlay hint requests.
+//
+// @since 3.17.0
+type InlayHintParams struct {
+	*WorkDoneProgressParams
+
+	// The text document.
+	TextDocument TextDocumentIdentifier `json:"textDocument,required"`
+
+	// The visible document range for which inlay hints should be computed.
+	Range Range `json:"range,required"`
+}
+
+// InlayHint Inlay hint information.
+//
+// @since 3.17.0
+type InlayHint struct {
+	// The position of this hint.
+	//
+	// If multiple hints have the same position, they will be shown in the order
+	// they appear in the response.
+	Position Position `json:"position,required"`
+
+	// The label of this hint. A human readable string or an array of
+	// InlayHintLabelPart label parts.
+	//
+	// *Note* that neither the string nor the label part can be empty.
+	Label InlayHintLabel `json:"label,required"`
+
+	// The kind of this hint. Can be omitted in which case the client
+	// should fall back to a reasonable default.
+	Kind InlayHintKind `json:"kind,omitempty"`
+
+	// Optional text edits that are performed when accepting this inlay hint.
+	//
+	// *Note* that edits are expected to change the document so that the inlay
+	// hint (or its nearest variant) is now part of the document and the inlay
+	// hint itself is now obsolete.
+	TextEdits []TextEdit `json:"textEdits,omitempty"`
+
+	// The tooltip text when you hover over this item.
+	// type: string | MarkupContent
+	Tooltip json.RawMessage `json:"tooltip,omitempty"`
+
+	// Render padding before the hint.
+	//
+	// Note: Padding should use the editor's background color, not the
+	// background color of the hint itself. That means padding can be used
+	// to visually align/separate an inlay hint.
+	PaddingLeft bool `json:"paddingLeft,omitempty"`
+
+	// Render padding after the hint.
+	//
+	// Note: Padding should use the editor's background color, not the
+	// background color of the hint itself. That means padding can be used
+	// to visually align/separate an inlay hint.
+	PaddingRight bool `json:"paddingRight,omitempty"`
+
+	// A data entry field that is preserved on an inlay hint between
+	// a `textDocument/inlayHint` and a `inlayHint/resolve` request.
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// InlayHintLabelPart An inlay hint label part allows for interactive and
+// composite labels of inlay hints.
+//
+// @since 3.17.0
+type InlayHintLabelPart struct {
+	// The value of this label part.
+	Value string `json:"value,required"`
+
+	// The tooltip text when you hover over this label part. Depending on
+	// the client capability `inlayHint.resolveSupport` clients might resolve
+	// this property late using the resolve request.
+	// type: string | MarkupContent
+	Tooltip json.RawMessage `json:"tooltip,omitempty"`
+
+	// An optional source code location that represents this
+	// label part.
+	//
+	// The editor will use this location for the hover and for code navigation
+	// features: This part will become a clickable link that resolves to the
+	// definition of the symbol at the given location (not necessarily the
+	// location itself), it shows the hover that shows at the given location,
+	// and it shows a context menu with further code navigation commands.
+	//
+	// Depending on the client capability `inlayHint.resolveSupport` clients
+	// might resolve this property late using the resolve request.
+	Location *Location `json:"location,omitempty"`
+
+	// An optional command for this label part.
+	//
+	// Depending on the client capability `inlayHint.resolveSupport` clients
+	// might resolve this property late using the resolve request.
+	Command *Command `json:"command,omitempty"`
+}
+
+// InlayHintKind Inlay hint kinds.
+//
+// @since 3.17.0
+type InlayHintKind int
+
+// InlayHintKindType An inlay hint that for a type annotation.
+const InlayHintKindType InlayHintKind = 1
+
+// InlayHintKindParameter An inlay hint that is for a parameter.
+const InlayHintKindParameter InlayHintKind = 2
+
+// TypeHierarchyPrepareParams The parameter of a `textDocument/prepareTypeHierarchy` request.
+//
+// @since 3.17.0
+type TypeHierarchyPrepareParams struct {
+	TextDocumentPositionParams
+	*WorkDoneProgressParams
+}
+
+// TypeHierarchyItem An item of the type hierarchy.
+//
+// @since 3.17.0
+type TypeHierarchyItem struct {
+	// The name of this item.
+	Name string `json:"name,required"`
+
+	// The kind of this item.
+	Kind SymbolKind `json:"kind,required"`
+
+	// Tags for this item.
+	Tags []SymbolTag `json:"tags,omitempty"`
+
+	// More detail for this item, e.g. the signature of a function.
+	Detail string `json:"detail,omitempty"`
+
+	// The resource identifier of this item.
+	URI DocumentURI `json:"uri,required"`
+
+	// The range enclosing this symbol not including leading/trailing whitespace
+	// but everything else, e.g. comments and code.
+	Range Range `json:"range,required"`
+
+	// The range that should be selected and revealed when this symbol is being
+	// picked, e.g. the name of a function. Must be contained by the
+	// [`range`](#TypeHierarchyItem.range).
+	SelectionRange Range `json:"selectionRange,required"`
+
+	// A data entry field that is preserved between a type hierarchy prepare and
+	// supertypes or subtypes requests. It could also be used to identify the
+	// type hierarchy in the server, helping improve the performance on
+	// resolving supertypes and subtypes.
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// TypeHierarchySupertypesParams The parameter of a `typeHierarchy/supertypes` request.
+//
+// @since 3.17.0
+type TypeHierarchySupertypesParams struct {
+	*WorkDoneProgressParams
+	*PartialResultParams
+
+	Item TypeHierarchyItem `json:"item,required"`
+}
+
+// TypeHierarchySubtypesParams The parameter of a `typeHierarchy/subtypes` request.
+//
+// @since 3.17.0
+type TypeHierarchySubtypesParams struct {
+	*WorkDoneProgressParams
+	*PartialResultParams
+
+	Item TypeHierarchyItem `json:"item,required"`
+}
+
+// InlineValueParams A parameter literal used in inline value requests.
+//
+// @since 3.17.0
+type InlineValueParams struct {
+	*WorkDoneProgressParams
+
+	// The text document.
+	TextDocument TextDocumentIdentifier `json:"textDocument,required"`
+
+	// The document range for which inline values should be computed.
+	Range Range `json:"range,required"`
+
+	// Additional information about the context in which inline values were
+	// requested.
+	Context InlineValueContext `json:"context,required"`
+}
+
+// InlineValueContext The context of an inline value request.
+//
+// @since 3.17.0
+type InlineValueContext struct {
+	// The stack frame (as a DAP Id) where the execution has stopped.
+	FrameID int `json:"frameId,required"`
+
+	// The document range where execution has stopped.
+	// Typically the end position of the range denotes the line where the
+	// inline values are shown.
+	StoppedLocation Range `json:"stoppedLocation,required"`
+}
+
+// InlineValueText Provide inline value as text.
+//
+// @since 3.17.0
+type InlineValueText struct {
+	// The document range for which the inline value applies.
+	Range Range `json:"range,required"`
+
+	// The text of the inline value.
+	Text string `json:"text,required"`
+}
+
+// InlineValueVariableLookup Provide inline value through a variable lookup.
+//
+// If only a range is specified, the variable name will be extracted from
+// the underlying document.
+//
+// An optional variable name can be used to override the extracted name.
+//
+// @since 3.17.0
+type InlineValueVariableLookup struct {
+	// The document range for which the inline value applies.
+	// The range is used to extract the variable name from the underlying
+	// document.
+	Range Range `json:"range,required"`
+
+	// If specified the name of the variable to look up.
+	VariableName string `json:"variableName,omitempty"`
+
+	// How to perform the lookup.
+	CaseSensitiveLookup bool `json:"caseSensitiveLookup,required"`
+}
+
+// InlineValueEvaluatableExpression Provide an inline value through an
+// expression evaluation.
+//
+// If only a range is specified, the expression will be extracted from the
+// underlying document.
+//
+// An optional expression can be used to override the extracted expression.
+//
+// @since 3.17.0
+type InlineValueEvaluatableExpression struct {
+	// The document range for which the inline value applies.
+	// The range is used to extract the evaluatable expression from the
+	// underlying document.
+	Range Range `json:"range,required"`
+
+	// If specified the expression overrides the extracted expression.
+	Expression string `json:"expression,omitempty"`
+}
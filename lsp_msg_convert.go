@@ -7,7 +7,10 @@
 package lsp
 
 import (
+	"fmt"
+
 	"go.bug.st/json"
+	"go.bug.st/lsp/jsonrpc"
 )
 
 // DecodeClientRequestParams parse a CLIENT-REQUEST (↩)
@@ -123,6 +126,15 @@ func DecodeClientRequestParams(method string, req json.RawMessage) (any, error)
 	case "callHierarchy/outgoingCalls":
 		var res CallHierarchyOutgoingCallsParams
 		return &res, json.Unmarshal(req, &res)
+	case "textDocument/prepareTypeHierarchy":
+		var res TypeHierarchyPrepareParams
+		return &res, json.Unmarshal(req, &res)
+	case "typeHierarchy/supertypes":
+		var res TypeHierarchySupertypesParams
+		return &res, json.Unmarshal(req, &res)
+	case "typeHierarchy/subtypes":
+		var res TypeHierarchySubtypesParams
+		return &res, json.Unmarshal(req, &res)
 	case "textDocument/semanticTokens/full":
 		var res SemanticTokensParams
 		return &res, json.Unmarshal(req, &res)
@@ -140,6 +152,21 @@ func DecodeClientRequestParams(method string, req json.RawMessage) (any, error)
 	case "textDocument/moniker":
 		var res MonikerParams
 		return &res, json.Unmarshal(req, &res)
+	case "textDocument/inlineValue":
+		var res InlineValueParams
+		return &res, json.Unmarshal(req, &res)
+	case "textDocument/inlayHint":
+		var res InlayHintParams
+		return &res, json.Unmarshal(req, &res)
+	case "inlayHint/resolve":
+		var res InlayHint
+		return &res, json.Unmarshal(req, &res)
+	case "textDocument/diagnostic":
+		var res DocumentDiagnosticParams
+		return &res, json.Unmarshal(req, &res)
+	case "workspace/diagnostic":
+		var res WorkspaceDiagnosticParams
+		return &res, json.Unmarshal(req, &res)
 	default:
 		panic("unimplemented message")
 	}
@@ -413,6 +440,27 @@ func DecodeServerResponseResult(method string, resp json.RawMessage) (interface{
 		}
 		var res []CallHierarchyOutgoingCall
 		return &res, json.Unmarshal(resp, &res)
+	case "textDocument/prepareTypeHierarchy":
+		// result: TypeHierarchyItem[] | null
+		if string(resp) == "null" {
+			return nil, nil
+		}
+		var res []TypeHierarchyItem
+		return &res, json.Unmarshal(resp, &res)
+	case "typeHierarchy/supertypes":
+		// result: TypeHierarchyItem[] | null
+		if string(resp) == "null" {
+			return nil, nil
+		}
+		var res []TypeHierarchyItem
+		return &res, json.Unmarshal(resp, &res)
+	case "typeHierarchy/subtypes":
+		// result: TypeHierarchyItem[] | null
+		if string(resp) == "null" {
+			return nil, nil
+		}
+		var res []TypeHierarchyItem
+		return &res, json.Unmarshal(resp, &res)
 	case "textDocument/semanticTokens/full":
 		// result: SemanticTokens | null
 		if string(resp) == "null" {
@@ -454,6 +502,29 @@ func DecodeServerResponseResult(method string, resp json.RawMessage) (interface{
 		}
 		var res []Moniker
 		return &res, json.Unmarshal(resp, &res)
+	case "textDocument/inlineValue":
+		// result: InlineValue[] | null
+		if string(resp) == "null" {
+			return nil, nil
+		}
+		var res []InlineValue
+		return &res, json.Unmarshal(resp, &res)
+	case "textDocument/inlayHint":
+		// result: InlayHint[] | null
+		if string(resp) == "null" {
+			return nil, nil
+		}
+		var res []InlayHint
+		return &res, json.Unmarshal(resp, &res)
+	case "inlayHint/resolve":
+		var res InlayHint
+		return &res, json.Unmarshal(resp, &res)
+	case "textDocument/diagnostic":
+		var res DocumentDiagnosticReport
+		return &res, json.Unmarshal(resp, &res)
+	case "workspace/diagnostic":
+		var res WorkspaceDiagnosticReport
+		return &res, json.Unmarshal(resp, &res)
 	default:
 		panic("unimplemented message")
 	}
@@ -487,6 +558,12 @@ func DecodeServerRequestParams(method string, req json.RawMessage) (interface{},
 		return &res, json.Unmarshal(req, &res)
 	case "workspace/codeLens/refresh":
 		return nil, nil
+	case "workspace/inlineValue/refresh":
+		return nil, nil
+	case "workspace/inlayHint/refresh":
+		return nil, nil
+	case "workspace/diagnostic/refresh":
+		return nil, nil
 	default:
 		panic("unimplemented message")
 	}
@@ -527,6 +604,12 @@ func DecodeClientResponseResult(method string, resp json.RawMessage) (interface{
 		return &res, json.Unmarshal(resp, &res)
 	case "workspace/codeLens/refresh":
 		return nil, nil
+	case "workspace/inlineValue/refresh":
+		return nil, nil
+	case "workspace/inlayHint/refresh":
+		return nil, nil
+	case "workspace/diagnostic/refresh":
+		return nil, nil
 	default:
 		panic("unimplemented message")
 	}
@@ -584,6 +667,18 @@ func DecodeClientNotificationParams(method string, req json.RawMessage) (interfa
 	case "textDocument/didClose":
 		var res DidCloseTextDocumentParams
 		return &res, json.Unmarshal(req, &res)
+	case "notebookDocument/didOpen":
+		var res DidOpenNotebookDocumentParams
+		return &res, json.Unmarshal(req, &res)
+	case "notebookDocument/didChange":
+		var res DidChangeNotebookDocumentParams
+		return &res, json.Unmarshal(req, &res)
+	case "notebookDocument/didSave":
+		var res DidSaveNotebookDocumentParams
+		return &res, json.Unmarshal(req, &res)
+	case "notebookDocument/didClose":
+		var res DidCloseNotebookDocumentParams
+		return &res, json.Unmarshal(req, &res)
 	default:
 		panic("unimplemented message")
 	}
@@ -617,7 +712,56 @@ func DecodeServerNotificationParams(method string, req json.RawMessage) (interfa
 	}
 }
 
+// EncodeMessage encodes msg in JSON.
+//
+// Deprecated: the encoding errors are discarded, use json.Marshal instead.
 func EncodeMessage(msg interface{}) json.RawMessage {
 	raw, _ := json.Marshal(msg)
 	return raw
 }
+
+// encodeParams encodes the params of an outgoing request or notification.
+func encodeParams(method string, params any) (json.RawMessage, error) {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("encoding params of %s: %w", method, err)
+	}
+	return raw, nil
+}
+
+// encodeResult encodes the result of an incoming request. If the result can
+// not be encoded the error is reported to errorHandler and an InternalError
+// response is returned in place of the result.
+func encodeResult(method string, res any, resErr *jsonrpc.ResponseError, errorHandler func(error)) (json.RawMessage, *jsonrpc.ResponseError) {
+	if resErr != nil {
+		return nil, resErr
+	}
+	raw, err := json.Marshal(res)
+	if err != nil {
+		err = fmt.Errorf("encoding result of %s: %w", method, err)
+		errorHandler(err)
+		return nil, &jsonrpc.ResponseError{
+			Code:    jsonrpc.ErrorCodesInternalError,
+			Message: err.Error(),
+		}
+	}
+	return raw, nil
+}
+
+// invalidParamsError returns the error response for a message with params
+// that could not be decoded.
+func invalidParamsError(err error) *jsonrpc.ResponseError {
+	return &jsonrpc.ResponseError{
+		Code:    jsonrpc.ErrorCodesInvalidParams,
+		Message: err.Error(),
+	}
+}
+
+// methodNotFoundError returns the error response for a request that is not
+// implemented.
+func methodNotFoundError(method string) *jsonrpc.ResponseError {
+	return &jsonrpc.ResponseError{
+		Code:    jsonrpc.ErrorCodesMethodNotFound,
+		Message: fmt.Sprintf("method not found: %s", method),
+	}
+}
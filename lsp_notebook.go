@@ -0,0 +1,231 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"fmt"
+
+	"go.bug.st/json"
+)
+
+// NotebookDocument A notebook document.
+//
+// @since 3.17.0
+type NotebookDocument struct {
+	// The notebook document's URI.
+	URI DocumentURI `json:"uri,required"`
+
+	// The type of the notebook.
+	NotebookType string `json:"notebookType,required"`
+
+	// The version number of this document (it will increase after each
+	// change, including undo/redo).
+	Version int `json:"version,required"`
+
+	// Additional metadata stored with the notebook
+	// document.
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+
+	// The cells of a notebook.
+	Cells []NotebookCell `json:"cells,required"`
+}
+
+func (n NotebookDocument) String() string {
+	return fmt.Sprintf("%s@%d as '%s'", n.URI, n.Version, n.NotebookType)
+}
+
+// NotebookCell A notebook cell.
+//
+// A cell's document URI must be unique across ALL notebook
+// cells and can therefore be used to uniquely identify a
+// notebook cell or the cell's text document.
+//
+// @since 3.17.0
+type NotebookCell struct {
+	// The cell's kind.
+	Kind NotebookCellKind `json:"kind,required"`
+
+	// The URI of the cell's text document
+	// content.
+	Document DocumentURI `json:"document,required"`
+
+	// Additional metadata stored with the cell.
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+
+	// Additional execution summary information
+	// if supported by the client.
+	ExecutionSummary *ExecutionSummary `json:"executionSummary,omitempty"`
+}
+
+// NotebookCellKind A notebook cell kind.
+//
+// @since 3.17.0
+type NotebookCellKind int
+
+// NotebookCellKindMarkup A markup-cell is formatted source that is used for display.
+const NotebookCellKindMarkup NotebookCellKind = 1
+
+// NotebookCellKindCode A code-cell is source code.
+const NotebookCellKindCode NotebookCellKind = 2
+
+// ExecutionSummary The execution summary of a notebook cell.
+//
+// @since 3.17.0
+type ExecutionSummary struct {
+	// A strict monotonically increasing value
+	// indicating the execution order of a cell
+	// inside a notebook.
+	ExecutionOrder int `json:"executionOrder,required"`
+
+	// Whether the execution was successful or
+	// not if known by the client.
+	Success *bool `json:"success,omitempty"`
+}
+
+// NotebookDocumentIdentifier A literal to identify a notebook document in the client.
+//
+// @since 3.17.0
+type NotebookDocumentIdentifier struct {
+	// The notebook document's URI.
+	URI DocumentURI `json:"uri,required"`
+}
+
+// VersionedNotebookDocumentIdentifier A versioned notebook document identifier.
+//
+// @since 3.17.0
+type VersionedNotebookDocumentIdentifier struct {
+	// The version number of this notebook document.
+	Version int `json:"version,required"`
+
+	// The notebook document's URI.
+	URI DocumentURI `json:"uri,required"`
+}
+
+func (v VersionedNotebookDocumentIdentifier) String() string {
+	return fmt.Sprintf("%s@%d", v.URI, v.Version)
+}
+
+// DidOpenNotebookDocumentParams The params sent in an open notebook document notification.
+//
+// @since 3.17.0
+type DidOpenNotebookDocumentParams struct {
+	// The notebook document that got opened.
+	NotebookDocument NotebookDocument `json:"notebookDocument,required"`
+
+	// The text documents that represent the content
+	// of a notebook cell.
+	CellTextDocuments []TextDocumentItem `json:"cellTextDocuments,required"`
+}
+
+// DidChangeNotebookDocumentParams The params sent in a change notebook document notification.
+//
+// @since 3.17.0
+type DidChangeNotebookDocumentParams struct {
+	// The notebook document that did change. The version number points
+	// to the version after all provided changes have been applied.
+	NotebookDocument VersionedNotebookDocumentIdentifier `json:"notebookDocument,required"`
+
+	// The actual changes to the notebook document.
+	//
+	// The change describes single state change to the notebook document.
+	// So it moves a notebook document, its cells and its cell text document
+	// contents from state S to S'.
+	//
+	// To mirror the content of a notebook using change events use the
+	// following approach:
+	// - start with the same initial content
+	// - apply the 'notebookDocument/didChange' notifications in the order
+	//   you receive them.
+	Change NotebookDocumentChangeEvent `json:"change,required"`
+}
+
+// NotebookDocumentChangeEvent A change event for a notebook document.
+//
+// @since 3.17.0
+type NotebookDocumentChangeEvent struct {
+	// The changed meta data if any.
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+
+	// Changes to cells
+	Cells *NotebookDocumentCellChanges `json:"cells,omitempty"`
+}
+
+// NotebookDocumentCellChanges Changes to the cells of a notebook document.
+//
+// @since 3.17.0
+type NotebookDocumentCellChanges struct {
+	// Changes to the cell structure to add or
+	// remove cells.
+	Structure *NotebookDocumentCellChangeStructure `json:"structure,omitempty"`
+
+	// Changes to notebook cells properties like its
+	// kind, execution summary or metadata.
+	Data []NotebookCell `json:"data,omitempty"`
+
+	// Changes to the text content of notebook cells.
+	TextContent []NotebookDocumentCellContentChanges `json:"textContent,omitempty"`
+}
+
+// NotebookDocumentCellChangeStructure Structural changes to the cells of a
+// notebook document.
+//
+// @since 3.17.0
+type NotebookDocumentCellChangeStructure struct {
+	// The change to the cell array.
+	Array NotebookCellArrayChange `json:"array,required"`
+
+	// Additional opened cell text documents.
+	DidOpen []TextDocumentItem `json:"didOpen,omitempty"`
+
+	// Additional closed cell text documents.
+	DidClose []TextDocumentIdentifier `json:"didClose,omitempty"`
+}
+
+// NotebookDocumentCellContentChanges Content changes to a cell of a notebook
+// document.
+//
+// @since 3.17.0
+type NotebookDocumentCellContentChanges struct {
+	Document VersionedTextDocumentIdentifier `json:"document,required"`
+
+	Changes []TextDocumentContentChangeEvent `json:"changes,required"`
+}
+
+// NotebookCellArrayChange A change describing how to move a `NotebookCell`
+// array from state S to S'.
+//
+// @since 3.17.0
+type NotebookCellArrayChange struct {
+	// The start offset of the cell that changed.
+	Start int `json:"start,required"`
+
+	// The deleted cells
+	DeleteCount int `json:"deleteCount,required"`
+
+	// The new cells, if any
+	Cells []NotebookCell `json:"cells,omitempty"`
+}
+
+// DidSaveNotebookDocumentParams The params sent in a save notebook document notification.
+//
+// @since 3.17.0
+type DidSaveNotebookDocumentParams struct {
+	// The notebook document that got saved.
+	NotebookDocument NotebookDocumentIdentifier `json:"notebookDocument,required"`
+}
+
+// DidCloseNotebookDocumentParams The params sent in a close notebook document notification.
+//
+// @since 3.17.0
+type DidCloseNotebookDocumentParams struct {
+	// The notebook document that got closed.
+	NotebookDocument NotebookDocumentIdentifier `json:"notebookDocument,required"`
+
+	// The text documents that represent the content
+	// of a notebook cell that got closed.
+	CellTextDocuments []TextDocumentIdentifier `json:"cellTextDocuments,required"`
+}
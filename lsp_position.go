@@ -54,6 +54,47 @@ func (p Position) AfterOrEq(q Position) bool {
 	return p.Line > q.Line || (p.Line == q.Line && p.Character >= q.Character)
 }
 
+// PositionEncodingKind A type indicating how positions are encoded,
+// specifically what column offsets mean.
+//
+// @since 3.17.0
+type PositionEncodingKind string
+
+// PositionEncodingKindUTF8 Character offsets count UTF-8 code units (e.g bytes).
+const PositionEncodingKindUTF8 PositionEncodingKind = "utf-8"
+
+// PositionEncodingKindUTF16 Character offsets count UTF-16 code units.
+//
+// This is the default and must always be supported
+// by servers
+const PositionEncodingKindUTF16 PositionEncodingKind = "utf-16"
+
+// PositionEncodingKindUTF32 Character offsets count UTF-32 code units.
+//
+// Implementation note: these are the same as Unicode code points,
+// so this `PositionEncodingKind` may also be used for an
+// encoding-agnostic representation of character offsets.
+const PositionEncodingKindUTF32 PositionEncodingKind = "utf-32"
+
+// NegotiatePositionEncoding returns the first of the position encodings
+// supported by the client that is also supported by the server, following
+// the client's order of preference. If there is no match, UTF-16 is returned
+// because it's the mandatory encoding. The result should be set in the
+// ServerCapabilities.PositionEncoding returned by the Initialize request.
+func NegotiatePositionEncoding(client *ClientCapabilities, supported ...PositionEncodingKind) PositionEncodingKind {
+	if client == nil || client.General == nil {
+		return PositionEncodingKindUTF16
+	}
+	for _, encoding := range client.General.PositionEncodings {
+		for _, s := range supported {
+			if encoding == s {
+				return encoding
+			}
+		}
+	}
+	return PositionEncodingKindUTF16
+}
+
 // Location represents a location inside a resource, such as a line inside a text file.
 type Location struct {
 	URI DocumentURI `json:"uri,required"`
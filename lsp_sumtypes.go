@@ -63,3 +63,281 @@ func (c *CommandOrCodeAction) UnmarshalJSON(data []byte) error {
 func (c CommandOrCodeAction) MarshalJSON() ([]byte, error) {
 	return json.Marshal(c.Get())
 }
+
+// InlayHintLabel string | InlayHintLabelPart[]
+type InlayHintLabel struct {
+	text  *string
+	parts []InlayHintLabelPart
+}
+
+func (l *InlayHintLabel) Set(value interface{}) {
+	l.text = nil
+	l.parts = nil
+	switch v := value.(type) {
+	case string:
+		l.text = &v
+	case []InlayHintLabelPart:
+		l.parts = v
+	default:
+		panic("value must be a string or an []InlayHintLabelPart")
+	}
+}
+
+func (l *InlayHintLabel) Get() interface{} {
+	if l.text != nil {
+		return *(l.text)
+	}
+	if l.parts != nil {
+		return l.parts
+	}
+	panic("empty value")
+}
+
+func (l *InlayHintLabel) UnmarshalJSON(data []byte) error {
+	l.text = nil
+	l.parts = nil
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		l.text = &text
+		return nil
+	}
+	var parts []InlayHintLabelPart
+	if err := json.Unmarshal(data, &parts); err == nil {
+		l.parts = parts
+		return nil
+	}
+	return errors.New("expected string or []InlayHintLabelPart")
+}
+
+func (l InlayHintLabel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.Get())
+}
+
+// FullOrUnchangedDocumentDiagnosticReport FullDocumentDiagnosticReport | UnchangedDocumentDiagnosticReport
+type FullOrUnchangedDocumentDiagnosticReport struct {
+	full      *FullDocumentDiagnosticReport
+	unchanged *UnchangedDocumentDiagnosticReport
+}
+
+func (r *FullOrUnchangedDocumentDiagnosticReport) Set(value interface{}) {
+	r.full = nil
+	r.unchanged = nil
+	switch v := value.(type) {
+	case *FullDocumentDiagnosticReport:
+		r.full = v
+	case FullDocumentDiagnosticReport:
+		r.full = &v
+	case *UnchangedDocumentDiagnosticReport:
+		r.unchanged = v
+	case UnchangedDocumentDiagnosticReport:
+		r.unchanged = &v
+	default:
+		panic("value must be a FullDocumentDiagnosticReport or a UnchangedDocumentDiagnosticReport")
+	}
+}
+
+func (r *FullOrUnchangedDocumentDiagnosticReport) Get() interface{} {
+	if r.full != nil {
+		return *(r.full)
+	}
+	if r.unchanged != nil {
+		return *(r.unchanged)
+	}
+	panic("empty value")
+}
+
+func (r *FullOrUnchangedDocumentDiagnosticReport) UnmarshalJSON(data []byte) error {
+	r.full = nil
+	r.unchanged = nil
+	var full FullDocumentDiagnosticReport
+	if err := json.Unmarshal(data, &full); err == nil {
+		r.full = &full
+		return nil
+	}
+	var unchanged UnchangedDocumentDiagnosticReport
+	if err := json.Unmarshal(data, &unchanged); err == nil {
+		r.unchanged = &unchanged
+		return nil
+	}
+	return errors.New("expected FullDocumentDiagnosticReport or UnchangedDocumentDiagnosticReport")
+}
+
+func (r FullOrUnchangedDocumentDiagnosticReport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Get())
+}
+
+// DocumentDiagnosticReport RelatedFullDocumentDiagnosticReport | RelatedUnchangedDocumentDiagnosticReport
+type DocumentDiagnosticReport struct {
+	full      *RelatedFullDocumentDiagnosticReport
+	unchanged *RelatedUnchangedDocumentDiagnosticReport
+}
+
+func (r *DocumentDiagnosticReport) Set(value interface{}) {
+	r.full = nil
+	r.unchanged = nil
+	switch v := value.(type) {
+	case *RelatedFullDocumentDiagnosticReport:
+		r.full = v
+	case RelatedFullDocumentDiagnosticReport:
+		r.full = &v
+	case *RelatedUnchangedDocumentDiagnosticReport:
+		r.unchanged = v
+	case RelatedUnchangedDocumentDiagnosticReport:
+		r.unchanged = &v
+	default:
+		panic("value must be a RelatedFullDocumentDiagnosticReport or a RelatedUnchangedDocumentDiagnosticReport")
+	}
+}
+
+func (r *DocumentDiagnosticReport) Get() interface{} {
+	if r.full != nil {
+		return *(r.full)
+	}
+	if r.unchanged != nil {
+		return *(r.unchanged)
+	}
+	panic("empty value")
+}
+
+func (r *DocumentDiagnosticReport) UnmarshalJSON(data []byte) error {
+	r.full = nil
+	r.unchanged = nil
+	var full RelatedFullDocumentDiagnosticReport
+	if err := json.Unmarshal(data, &full); err == nil {
+		r.full = &full
+		return nil
+	}
+	var unchanged RelatedUnchangedDocumentDiagnosticReport
+	if err := json.Unmarshal(data, &unchanged); err == nil {
+		r.unchanged = &unchanged
+		return nil
+	}
+	return errors.New("expected RelatedFullDocumentDiagnosticReport or RelatedUnchangedDocumentDiagnosticReport")
+}
+
+func (r DocumentDiagnosticReport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Get())
+}
+
+// WorkspaceDocumentDiagnosticReport WorkspaceFullDocumentDiagnosticReport | WorkspaceUnchangedDocumentDiagnosticReport
+type WorkspaceDocumentDiagnosticReport struct {
+	full      *WorkspaceFullDocumentDiagnosticReport
+	unchanged *WorkspaceUnchangedDocumentDiagnosticReport
+}
+
+func (r *WorkspaceDocumentDiagnosticReport) Set(value interface{}) {
+	r.full = nil
+	r.unchanged = nil
+	switch v := value.(type) {
+	case *WorkspaceFullDocumentDiagnosticReport:
+		r.full = v
+	case WorkspaceFullDocumentDiagnosticReport:
+		r.full = &v
+	case *WorkspaceUnchangedDocumentDiagnosticReport:
+		r.unchanged = v
+	case WorkspaceUnchangedDocumentDiagnosticReport:
+		r.unchanged = &v
+	default:
+		panic("value must be a WorkspaceFullDocumentDiagnosticReport or a WorkspaceUnchangedDocumentDiagnosticReport")
+	}
+}
+
+func (r *WorkspaceDocumentDiagnosticReport) Get() interface{} {
+	if r.full != nil {
+		return *(r.full)
+	}
+	if r.unchanged != nil {
+		return *(r.unchanged)
+	}
+	panic("empty value")
+}
+
+func (r *WorkspaceDocumentDiagnosticReport) UnmarshalJSON(data []byte) error {
+	r.full = nil
+	r.unchanged = nil
+	var full WorkspaceFullDocumentDiagnosticReport
+	if err := json.Unmarshal(data, &full); err == nil {
+		r.full = &full
+		return nil
+	}
+	var unchanged WorkspaceUnchangedDocumentDiagnosticReport
+	if err := json.Unmarshal(data, &unchanged); err == nil {
+		r.unchanged = &unchanged
+		return nil
+	}
+	return errors.New("expected WorkspaceFullDocumentDiagnosticReport or WorkspaceUnchangedDocumentDiagnosticReport")
+}
+
+func (r WorkspaceDocumentDiagnosticReport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Get())
+}
+
+// InlineValue InlineValueText | InlineValueVariableLookup | InlineValueEvaluatableExpression
+type InlineValue struct {
+	text                  *InlineValueText
+	variableLookup        *InlineValueVariableLookup
+	evaluatableExpression *InlineValueEvaluatableExpression
+}
+
+func (v *InlineValue) Set(value interface{}) {
+	v.text = nil
+	v.variableLookup = nil
+	v.evaluatableExpression = nil
+	switch val := value.(type) {
+	case *InlineValueText:
+		v.text = val
+	case InlineValueText:
+		v.text = &val
+	case *InlineValueVariableLookup:
+		v.variableLookup = val
+	case InlineValueVariableLookup:
+		v.variableLookup = &val
+	case *InlineValueEvaluatableExpression:
+		v.evaluatableExpression = val
+	case InlineValueEvaluatableExpression:
+		v.evaluatableExpression = &val
+	default:
+		panic("value must be an InlineValueText, an InlineValueVariableLookup or an InlineValueEvaluatableExpression")
+	}
+}
+
+func (v *InlineValue) Get() interface{} {
+	if v.text != nil {
+		return *(v.text)
+	}
+	if v.variableLookup != nil {
+		return *(v.variableLookup)
+	}
+	if v.evaluatableExpression != nil {
+		return *(v.evaluatableExpression)
+	}
+	panic("empty value")
+}
+
+func (v *InlineValue) UnmarshalJSON(data []byte) error {
+	v.text = nil
+	v.variableLookup = nil
+	v.evaluatableExpression = nil
+	// The variants are told apart by their required fields, the evaluatable
+	// expression requires only the range so it's tried last.
+	var text InlineValueText
+	if err := json.Unmarshal(data, &text); err == nil {
+		v.text = &text
+		return nil
+	}
+	var variableLookup InlineValueVariableLookup
+	if err := json.Unmarshal(data, &variableLookup); err == nil {
+		v.variableLookup = &variableLookup
+		return nil
+	}
+	var evaluatableExpression InlineValueEvaluatableExpression
+	if err := json.Unmarshal(data, &evaluatableExpression); err == nil {
+		v.evaluatableExpression = &evaluatableExpression
+		return nil
+	}
+	return errors.New("expected InlineValueText, InlineValueVariableLookup or InlineValueEvaluatableExpression")
+}
+
+func (v InlineValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Get())
+}
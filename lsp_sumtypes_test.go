@@ -120,3 +120,122 @@ func TestSumTypes(t *testing.T) {
 		require.IsType(t, CodeAction{}, resArray[0].Get())
 	}
 }
+
+func TestInlayHintLabel(t *testing.T) {
+	{
+		var l InlayHintLabel
+		l.Set("label")
+		data, err := json.Marshal(l)
+		require.NoError(t, err)
+		require.Equal(t, `"label"`, string(data))
+	}
+	{
+		var l InlayHintLabel
+		l.Set([]InlayHintLabelPart{{Value: "a"}, {Value: "b", Command: &Command{Title: "t", Command: "c"}}})
+		data, err := json.Marshal(l)
+		require.NoError(t, err)
+		require.Equal(t, `[{"value":"a"},{"value":"b","command":{"title":"t","command":"c"}}]`, string(data))
+	}
+
+	{
+		var hint InlayHint
+		err := json.Unmarshal([]byte(`{"position":{"line":1,"character":2},"label":"name:","kind":2}`), &hint)
+		require.NoError(t, err)
+		require.Equal(t, "name:", hint.Label.Get())
+		require.Equal(t, InlayHintKindParameter, hint.Kind)
+	}
+	{
+		var hint InlayHint
+		err := json.Unmarshal([]byte(`{"position":{"line":1,"character":2},"label":[{"value":"int"}]}`), &hint)
+		require.NoError(t, err)
+		require.Equal(t, []InlayHintLabelPart{{Value: "int"}}, hint.Label.Get())
+	}
+	{
+		var l InlayHintLabel
+		require.Error(t, json.Unmarshal([]byte(`42`), &l))
+	}
+}
+
+func TestDocumentDiagnosticReport(t *testing.T) {
+	{
+		var r DocumentDiagnosticReport
+		r.Set(RelatedFullDocumentDiagnosticReport{ResultID: "1"})
+		data, err := json.Marshal(r)
+		require.NoError(t, err)
+		require.Equal(t, `{"kind":"full","resultId":"1","items":[]}`, string(data))
+	}
+	{
+		var related FullOrUnchangedDocumentDiagnosticReport
+		related.Set(UnchangedDocumentDiagnosticReport{ResultID: "2"})
+		var r DocumentDiagnosticReport
+		r.Set(RelatedUnchangedDocumentDiagnosticReport{
+			ResultID:         "1",
+			RelatedDocuments: map[DocumentURI]FullOrUnchangedDocumentDiagnosticReport{NewDocumentURI("/b.h"): related},
+		})
+		data, err := json.Marshal(r)
+		require.NoError(t, err)
+		require.Equal(t, `{"kind":"unchanged","resultId":"1","relatedDocuments":{"file:///b.h":{"kind":"unchanged","resultId":"2"}}}`, string(data))
+	}
+
+	{
+		var r DocumentDiagnosticReport
+		err := json.Unmarshal([]byte(`{"kind":"full","items":[{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":1}},"message":"error"}]}`), &r)
+		require.NoError(t, err)
+		require.IsType(t, RelatedFullDocumentDiagnosticReport{}, r.Get())
+		require.Equal(t, "error", r.Get().(RelatedFullDocumentDiagnosticReport).Items[0].Message)
+	}
+	{
+		var r DocumentDiagnosticReport
+		err := json.Unmarshal([]byte(`{"kind":"unchanged","resultId":"1","relatedDocuments":{"file:///b.h":{"kind":"full","items":[]}}}`), &r)
+		require.NoError(t, err)
+		require.IsType(t, RelatedUnchangedDocumentDiagnosticReport{}, r.Get())
+		related := r.Get().(RelatedUnchangedDocumentDiagnosticReport).RelatedDocuments[NewDocumentURI("/b.h")]
+		require.IsType(t, FullDocumentDiagnosticReport{}, related.Get())
+	}
+	{
+		var r DocumentDiagnosticReport
+		require.Error(t, json.Unmarshal([]byte(`{"kind":"partial","resultId":"1"}`), &r))
+	}
+
+	{
+		version := 3
+		var item WorkspaceDocumentDiagnosticReport
+		item.Set(WorkspaceFullDocumentDiagnosticReport{URI: NewDocumentURI("/a.c"), Version: &version})
+		data, err := json.Marshal(WorkspaceDiagnosticReport{Items: []WorkspaceDocumentDiagnosticReport{item}})
+		require.NoError(t, err)
+		require.Equal(t, `{"items":[{"kind":"full","items":[],"uri":"file:///a.c","version":3}]}`, string(data))
+
+		var res WorkspaceDiagnosticReport
+		require.NoError(t, json.Unmarshal(data, &res))
+		require.Equal(t, &version, res.Items[0].Get().(WorkspaceFullDocumentDiagnosticReport).Version)
+	}
+}
+
+func TestInlineValue(t *testing.T) {
+	rangeJSON := `{"start":{"line":1,"character":0},"end":{"line":1,"character":3}}`
+	{
+		var v InlineValue
+		require.NoError(t, json.Unmarshal([]byte(`{"range":`+rangeJSON+`,"text":"x = 1"}`), &v))
+		require.IsType(t, InlineValueText{}, v.Get())
+	}
+	{
+		var v InlineValue
+		require.NoError(t, json.Unmarshal([]byte(`{"range":`+rangeJSON+`,"variableName":"x","caseSensitiveLookup":true}`), &v))
+		require.Equal(t, "x", v.Get().(InlineValueVariableLookup).VariableName)
+	}
+	{
+		var v InlineValue
+		require.NoError(t, json.Unmarshal([]byte(`{"range":`+rangeJSON+`,"expression":"a + b"}`), &v))
+		require.Equal(t, "a + b", v.Get().(InlineValueEvaluatableExpression).Expression)
+	}
+	{
+		var v InlineValue
+		require.Error(t, json.Unmarshal([]byte(`{"text":"x = 1"}`), &v))
+	}
+
+	var v InlineValue
+	v.Set(InlineValueVariableLookup{Range: Range{Start: Position{Line: 1}, End: Position{Line: 1, Character: 3}}})
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, `{"range":`+rangeJSON+`,"caseSensitiveLookup":false}`, string(data))
+}
@@ -0,0 +1,22 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+//go:build !windows
+
+package lsp
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive returns true if the process with the given pid is running.
+func processAlive(pid int) bool {
+	// The signal 0 performs only the error checking: EPERM means that the
+	// process exists but belongs to another user.
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
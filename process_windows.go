@@ -0,0 +1,32 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+//go:build windows
+
+package lsp
+
+import (
+	"errors"
+	"syscall"
+)
+
+const processQueryLimitedInformation = 0x1000
+const stillActive = 259
+
+// processAlive returns true if the process with the given pid is running.
+func processAlive(pid int) bool {
+	h, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		// The process exists but we are not allowed to query it
+		return errors.Is(err, syscall.ERROR_ACCESS_DENIED)
+	}
+	defer syscall.CloseHandle(h)
+	var exitCode uint32
+	if err := syscall.GetExitCodeProcess(h, &exitCode); err != nil {
+		return true
+	}
+	return exitCode == stillActive
+}
@@ -8,80 +8,31 @@ package lsp
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"strings"
+	"sync"
+	"time"
 
 	"go.bug.st/json"
 	"go.bug.st/lsp/jsonrpc"
 )
 
-// ClientMessagesHandler interface has all the methods that an LSP Server should
-// implement to correctly parse incoming messages
+// ClientMessagesHandler interface has the methods that an LSP Server must
+// implement to handle the lifecycle of the session. All the other features
+// are supported by implementing the corresponding optional interfaces
+// (HoverProvider, DefinitionProvider, TextDocumentSyncHandler, etc.).
 type ClientMessagesHandler interface {
 	// Request -> Response
 
 	Initialize(context.Context, jsonrpc.FunctionLogger, *InitializeParams) (*InitializeResult, *jsonrpc.ResponseError)
 	Shutdown(context.Context, jsonrpc.FunctionLogger) *jsonrpc.ResponseError
-	WorkspaceSymbol(context.Context, jsonrpc.FunctionLogger, *WorkspaceSymbolParams) ([]SymbolInformation, *jsonrpc.ResponseError)
-	WorkspaceExecuteCommand(context.Context, jsonrpc.FunctionLogger, *ExecuteCommandParams) (json.RawMessage, *jsonrpc.ResponseError)
-	WorkspaceWillCreateFiles(context.Context, jsonrpc.FunctionLogger, *CreateFilesParams) (*WorkspaceEdit, *jsonrpc.ResponseError)
-	WorkspaceWillRenameFiles(context.Context, jsonrpc.FunctionLogger, *RenameFilesParams) (*WorkspaceEdit, *jsonrpc.ResponseError)
-	WorkspaceWillDeleteFiles(context.Context, jsonrpc.FunctionLogger, *DeleteFilesParams) (*WorkspaceEdit, *jsonrpc.ResponseError)
-	TextDocumentWillSaveWaitUntil(context.Context, jsonrpc.FunctionLogger, *WillSaveTextDocumentParams) ([]TextEdit, *jsonrpc.ResponseError)
-	TextDocumentCompletion(context.Context, jsonrpc.FunctionLogger, *CompletionParams) (*CompletionList, *jsonrpc.ResponseError)
-	CompletionItemResolve(context.Context, jsonrpc.FunctionLogger, *CompletionItem) (*CompletionItem, *jsonrpc.ResponseError)
-	TextDocumentHover(context.Context, jsonrpc.FunctionLogger, *HoverParams) (*Hover, *jsonrpc.ResponseError)
-	TextDocumentSignatureHelp(context.Context, jsonrpc.FunctionLogger, *SignatureHelpParams) (*SignatureHelp, *jsonrpc.ResponseError)
-	TextDocumentDeclaration(context.Context, jsonrpc.FunctionLogger, *DeclarationParams) ([]Location, []LocationLink, *jsonrpc.ResponseError)
-	TextDocumentDefinition(context.Context, jsonrpc.FunctionLogger, *DefinitionParams) ([]Location, []LocationLink, *jsonrpc.ResponseError)
-	TextDocumentTypeDefinition(context.Context, jsonrpc.FunctionLogger, *TypeDefinitionParams) ([]Location, []LocationLink, *jsonrpc.ResponseError)
-	TextDocumentImplementation(context.Context, jsonrpc.FunctionLogger, *ImplementationParams) ([]Location, []LocationLink, *jsonrpc.ResponseError)
-	TextDocumentReferences(context.Context, jsonrpc.FunctionLogger, *ReferenceParams) ([]Location, *jsonrpc.ResponseError)
-	TextDocumentDocumentHighlight(context.Context, jsonrpc.FunctionLogger, *DocumentHighlightParams) ([]DocumentHighlight, *jsonrpc.ResponseError)
-	TextDocumentDocumentSymbol(context.Context, jsonrpc.FunctionLogger, *DocumentSymbolParams) ([]DocumentSymbol, []SymbolInformation, *jsonrpc.ResponseError)
-	TextDocumentCodeAction(context.Context, jsonrpc.FunctionLogger, *CodeActionParams) ([]CommandOrCodeAction, *jsonrpc.ResponseError)
-	CodeActionResolve(context.Context, jsonrpc.FunctionLogger, *CodeAction) (*CodeAction, *jsonrpc.ResponseError)
-	TextDocumentCodeLens(context.Context, jsonrpc.FunctionLogger, *CodeLensParams) ([]CodeLens, *jsonrpc.ResponseError)
-	CodeLensResolve(context.Context, jsonrpc.FunctionLogger, *CodeLens) (*CodeLens, *jsonrpc.ResponseError)
-	TextDocumentDocumentLink(context.Context, jsonrpc.FunctionLogger, *DocumentLinkParams) ([]DocumentLink, *jsonrpc.ResponseError)
-	DocumentLinkResolve(context.Context, jsonrpc.FunctionLogger, *DocumentLink) (*DocumentLink, *jsonrpc.ResponseError)
-	TextDocumentDocumentColor(context.Context, jsonrpc.FunctionLogger, *DocumentColorParams) ([]ColorInformation, *jsonrpc.ResponseError)
-	TextDocumentColorPresentation(context.Context, jsonrpc.FunctionLogger, *ColorPresentationParams) ([]ColorPresentation, *jsonrpc.ResponseError)
-	TextDocumentFormatting(context.Context, jsonrpc.FunctionLogger, *DocumentFormattingParams) ([]TextEdit, *jsonrpc.ResponseError)
-	TextDocumentRangeFormatting(context.Context, jsonrpc.FunctionLogger, *DocumentRangeFormattingParams) ([]TextEdit, *jsonrpc.ResponseError)
-	TextDocumentOnTypeFormatting(context.Context, jsonrpc.FunctionLogger, *DocumentOnTypeFormattingParams) ([]TextEdit, *jsonrpc.ResponseError)
-	TextDocumentRename(context.Context, jsonrpc.FunctionLogger, *RenameParams) (*WorkspaceEdit, *jsonrpc.ResponseError)
-	//TextDocumentPrepareRename(context.Context,jsonrpc.FunctionLogger, *PrepareRenameParams) (???, *jsonrpc.ResponseError)
-	TextDocumentFoldingRange(context.Context, jsonrpc.FunctionLogger, *FoldingRangeParams) ([]FoldingRange, *jsonrpc.ResponseError)
-	TextDocumentSelectionRange(context.Context, jsonrpc.FunctionLogger, *SelectionRangeParams) ([]SelectionRange, *jsonrpc.ResponseError)
-	TextDocumentPrepareCallHierarchy(context.Context, jsonrpc.FunctionLogger, *CallHierarchyPrepareParams) ([]CallHierarchyItem, *jsonrpc.ResponseError)
-	CallHierarchyIncomingCalls(context.Context, jsonrpc.FunctionLogger, *CallHierarchyIncomingCallsParams) ([]CallHierarchyIncomingCall, *jsonrpc.ResponseError)
-	CallHierarchyOutgoingCalls(context.Context, jsonrpc.FunctionLogger, *CallHierarchyOutgoingCallsParams) ([]CallHierarchyOutgoingCall, *jsonrpc.ResponseError)
-	TextDocumentSemanticTokensFull(context.Context, jsonrpc.FunctionLogger, *SemanticTokensParams) (*SemanticTokens, *jsonrpc.ResponseError)
-	TextDocumentSemanticTokensFullDelta(context.Context, jsonrpc.FunctionLogger, *SemanticTokensDeltaParams) (*SemanticTokens, *SemanticTokensDelta, *jsonrpc.ResponseError)
-	TextDocumentSemanticTokensRange(context.Context, jsonrpc.FunctionLogger, *SemanticTokensRangeParams) (*SemanticTokens, *jsonrpc.ResponseError)
-	WorkspaceSemanticTokensRefresh(context.Context, jsonrpc.FunctionLogger) *jsonrpc.ResponseError
-	TextDocumentLinkedEditingRange(context.Context, jsonrpc.FunctionLogger, *LinkedEditingRangeParams) (*LinkedEditingRanges, *jsonrpc.ResponseError)
-	TextDocumentMoniker(context.Context, jsonrpc.FunctionLogger, *MonikerParams) ([]Moniker, *jsonrpc.ResponseError)
 
 	// Notifications ->
 
-	Progress(jsonrpc.FunctionLogger, *ProgressParams)
 	// CancelRequrest(*jsonrpc.CancelParams) - automatically handled by the rpc library
 	Initialized(jsonrpc.FunctionLogger, *InitializedParams)
 	Exit(jsonrpc.FunctionLogger)
-	SetTrace(jsonrpc.FunctionLogger, *SetTraceParams)
-	WindowWorkDoneProgressCancel(jsonrpc.FunctionLogger, *WorkDoneProgressCancelParams)
-	WorkspaceDidChangeWorkspaceFolders(jsonrpc.FunctionLogger, *DidChangeWorkspaceFoldersParams)
-	WorkspaceDidChangeConfiguration(jsonrpc.FunctionLogger, *DidChangeConfigurationParams)
-	WorkspaceDidChangeWatchedFiles(jsonrpc.FunctionLogger, *DidChangeWatchedFilesParams)
-	WorkspaceDidCreateFiles(jsonrpc.FunctionLogger, *CreateFilesParams)
-	WorkspaceDidRenameFiles(jsonrpc.FunctionLogger, *RenameFilesParams)
-	WorkspaceDidDeleteFiles(jsonrpc.FunctionLogger, *DeleteFilesParams)
-	TextDocumentDidOpen(jsonrpc.FunctionLogger, *DidOpenTextDocumentParams)
-	TextDocumentDidChange(jsonrpc.FunctionLogger, *DidChangeTextDocumentParams)
-	TextDocumentWillSave(jsonrpc.FunctionLogger, *WillSaveTextDocumentParams)
-	TextDocumentDidSave(jsonrpc.FunctionLogger, *DidSaveTextDocumentParams)
-	TextDocumentDidClose(jsonrpc.FunctionLogger, *DidCloseTextDocumentParams)
 }
 
 // Server is an LSP Server
@@ -91,6 +42,36 @@ type Server struct {
 	customNotification map[string]CustomNotification
 	customRequest      map[string]CustomRequest
 	errorHandler       func(e error)
+
+	parentWatchdogInterval time.Duration
+	parentWatchdogOnce     sync.Once
+	lifecycle              lifecycle
+	fillCapabilities       bool
+}
+
+// ConnectionOption is an option for NewServer and NewClient.
+type ConnectionOption func(*connectionOptions)
+
+type connectionOptions struct {
+	framer jsonrpc.FramerFactory
+}
+
+func newConnectionOptions(opts []ConnectionOption) *connectionOptions {
+	options := &connectionOptions{
+		framer: jsonrpc.NewHeaderFramer,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithFramer sets how the messages are framed on the wire. The default is
+// the framing of the LSP base protocol (jsonrpc.NewHeaderFramer).
+func WithFramer(framer jsonrpc.FramerFactory) ConnectionOption {
+	return func(o *connectionOptions) {
+		o.framer = framer
+	}
 }
 
 // CustomNotification is a function type for incoming custom notifications callbacks
@@ -99,18 +80,26 @@ type CustomNotification func(logger jsonrpc.FunctionLogger, req json.RawMessage)
 // CustomRequest is a function type for incoming custom requests callbacks
 type CustomRequest func(ctx context.Context, logger jsonrpc.FunctionLogger, req json.RawMessage) (res interface{}, err *jsonrpc.ResponseError)
 
-func NewServer(in io.Reader, out io.Writer, handler ClientMessagesHandler) *Server {
+func NewServer(in io.Reader, out io.Writer, handler ClientMessagesHandler, opts ...ConnectionOption) *Server {
+	options := newConnectionOptions(opts)
+	return NewServerWithFramer(options.framer(in, out), handler)
+}
+
+// NewServerWithFramer creates a Server that exchanges the messages through
+// the given Framer. It is useful for message-oriented transports, like
+// WebSocket, that are not backed by a pair of streams.
+func NewServerWithFramer(framer jsonrpc.Framer, handler ClientMessagesHandler) *Server {
 	serv := &Server{
 		errorHandler:       func(e error) {},
 		customNotification: map[string]CustomNotification{},
 		customRequest:      map[string]CustomRequest{},
 	}
 	serv.handler = handler
-	serv.conn = jsonrpc.NewConnection(
-		in, out,
+	serv.conn = jsonrpc.NewConnectionWithFramer(
+		framer,
 		serv.requestDispatcher,
 		serv.notificationDispatcher,
-		serv.errorHandler)
+		func(e error) { serv.errorHandler(e) })
 	return serv
 }
 
@@ -118,10 +107,52 @@ func (serv *Server) SetLogger(l jsonrpc.Logger) {
 	serv.conn.SetLogger(l)
 }
 
+// SetRequestsConcurrency sets how many incoming requests may be handled
+// concurrently, see jsonrpc.Connection.SetRequestsConcurrency for details.
+// A concurrent mode is required if a handler needs to send a request
+// to the other side and wait for the response.
+func (serv *Server) SetRequestsConcurrency(n int) {
+	serv.conn.SetRequestsConcurrency(n)
+}
+
 func (serv *Server) SetErrorHandler(handler func(e error)) {
 	serv.errorHandler = handler
 }
 
+// SetParentProcessWatchdog enables the monitoring of the parent process,
+// as required by the LSP specification. Once the initialize request is
+// received, the process with the InitializeParams.ProcessID is checked at
+// the given interval: when it terminates the in-flight requests are
+// cancelled, the connection is closed and the handler Exit is called.
+// The watchdog is disabled by default (interval 0).
+func (serv *Server) SetParentProcessWatchdog(interval time.Duration) {
+	serv.parentWatchdogInterval = interval
+}
+
+// SetLifecycleGuard enables or disables the enforcement of the lifecycle
+// rules of the specification: the requests received before initialize are
+// rejected with ServerNotInitialized, the requests received after shutdown are
+// rejected with InvalidRequest, and the notifications received before
+// initialized or after shutdown are dropped (except exit).
+// The guard is disabled by default.
+func (serv *Server) SetLifecycleGuard(enabled bool) {
+	serv.lifecycle.setGuard(enabled)
+}
+
+// SetFillServerCapabilities enables or disables the completion of the
+// ServerCapabilities returned by Initialize with the capabilities of the
+// optional interfaces implemented by the handler (see FillServerCapabilities).
+// The capabilities set by the handler are never modified.
+// It is disabled by default.
+func (serv *Server) SetFillServerCapabilities(enabled bool) {
+	serv.fillCapabilities = enabled
+}
+
+// LifecycleState returns the current lifecycle state of the session.
+func (serv *Server) LifecycleState() LifecycleState {
+	return serv.lifecycle.current()
+}
+
 func (serv *Server) RegisterCustomNotification(method string, callback CustomNotification) {
 	serv.customNotification[method] = callback
 }
@@ -130,19 +161,64 @@ func (serv *Server) RegisterCustomRequest(method string, callback CustomRequest)
 	serv.customRequest[method] = callback
 }
 
+func (serv *Server) sendRequest(ctx context.Context, method string, params any) (json.RawMessage, *jsonrpc.ResponseError, error) {
+	raw, err := encodeParams(method, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return serv.conn.SendRequest(ctx, method, raw)
+}
+
+func (serv *Server) sendNotification(method string, params any) error {
+	raw, err := encodeParams(method, params)
+	if err != nil {
+		return err
+	}
+	return serv.conn.SendNotification(method, raw)
+}
+
+func (serv *Server) reportError(err error) {
+	serv.errorHandler(err)
+}
+
 func (serv *Server) Run() {
 	serv.conn.Run()
 }
 
+// RunWithContext is like Run but closes the connection when the given
+// context is done.
+func (serv *Server) RunWithContext(ctx context.Context) {
+	serv.conn.RunWithContext(ctx)
+}
+
+// WaitPendingResponses waits until all the incoming requests have been
+// answered, or until the given context is done.
+func (serv *Server) WaitPendingResponses(ctx context.Context) error {
+	return serv.conn.WaitPendingResponses(ctx)
+}
+
+// Close closes the connection, see jsonrpc.Connection.Close for details.
+func (serv *Server) Close() {
+	serv.conn.Close()
+}
+
 func (serv *Server) notificationDispatcher(logger jsonrpc.FunctionLogger, method string, req json.RawMessage) {
+	if err := serv.lifecycle.notification(method); err != nil {
+		logger.Logf("Notification dropped: %s", err)
+		return
+	}
 	switch method {
 	case "$/progress":
+		h, ok := serv.handler.(ProgressHandler)
+		if !ok {
+			return
+		}
 		var param ProgressParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.Progress(logger, &param)
+		h.Progress(logger, &param)
 	case "$/cancelRequrest":
 		panic("should not reach here")
 	case "initialized":
@@ -153,416 +229,826 @@ func (serv *Server) notificationDispatcher(logger jsonrpc.FunctionLogger, method
 		}
 		serv.handler.Initialized(logger, &param)
 	case "exit":
+		// Nothing is expected after the exit notification: the connection
+		// is closed even if the handler doesn't terminate the process.
+		defer serv.conn.Close()
 		serv.handler.Exit(logger)
 	case "$/setTrace", "$/setTraceNotification":
+		h, ok := serv.handler.(SetTraceHandler)
+		if !ok {
+			return
+		}
 		var param SetTraceParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.SetTrace(logger, &param)
+		h.SetTrace(logger, &param)
 	case "window/workDoneProgress/cancel":
+		h, ok := serv.handler.(WorkDoneProgressCancelHandler)
+		if !ok {
+			return
+		}
 		var param WorkDoneProgressCancelParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.WindowWorkDoneProgressCancel(logger, &param)
+		h.WindowWorkDoneProgressCancel(logger, &param)
 	case "workspace/didChangeWorkspaceFolders":
+		h, ok := serv.handler.(DidChangeWorkspaceFoldersHandler)
+		if !ok {
+			return
+		}
 		var param DidChangeWorkspaceFoldersParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.WorkspaceDidChangeWorkspaceFolders(logger, &param)
+		h.WorkspaceDidChangeWorkspaceFolders(logger, &param)
 	case "workspace/didChangeConfiguration":
+		h, ok := serv.handler.(DidChangeConfigurationHandler)
+		if !ok {
+			return
+		}
 		var param DidChangeConfigurationParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.WorkspaceDidChangeConfiguration(logger, &param)
+		h.WorkspaceDidChangeConfiguration(logger, &param)
 	case "workspace/didChangeWatchedFiles":
+		h, ok := serv.handler.(DidChangeWatchedFilesHandler)
+		if !ok {
+			return
+		}
 		var param DidChangeWatchedFilesParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.WorkspaceDidChangeWatchedFiles(logger, &param)
+		h.WorkspaceDidChangeWatchedFiles(logger, &param)
 	case "workspace/didCreateFiles":
+		h, ok := serv.handler.(DidCreateFilesHandler)
+		if !ok {
+			return
+		}
 		var param CreateFilesParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.WorkspaceDidCreateFiles(logger, &param)
+		h.WorkspaceDidCreateFiles(logger, &param)
 	case "workspace/didRenameFiles":
+		h, ok := serv.handler.(DidRenameFilesHandler)
+		if !ok {
+			return
+		}
 		var param RenameFilesParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.WorkspaceDidRenameFiles(logger, &param)
+		h.WorkspaceDidRenameFiles(logger, &param)
 	case "workspace/didDeleteFiles":
+		h, ok := serv.handler.(DidDeleteFilesHandler)
+		if !ok {
+			return
+		}
 		var param DeleteFilesParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.WorkspaceDidDeleteFiles(logger, &param)
+		h.WorkspaceDidDeleteFiles(logger, &param)
 	case "textDocument/didOpen":
+		h, ok := serv.handler.(TextDocumentSyncHandler)
+		if !ok {
+			return
+		}
 		var param DidOpenTextDocumentParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.TextDocumentDidOpen(logger, &param)
+		h.TextDocumentDidOpen(logger, &param)
 	case "textDocument/didChange":
+		h, ok := serv.handler.(TextDocumentSyncHandler)
+		if !ok {
+			return
+		}
 		var param DidChangeTextDocumentParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.TextDocumentDidChange(logger, &param)
+		h.TextDocumentDidChange(logger, &param)
 	case "textDocument/willSave":
+		h, ok := serv.handler.(WillSaveHandler)
+		if !ok {
+			return
+		}
 		var param WillSaveTextDocumentParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.TextDocumentWillSave(logger, &param)
+		h.TextDocumentWillSave(logger, &param)
 	case "textDocument/didSave":
+		h, ok := serv.handler.(DidSaveHandler)
+		if !ok {
+			return
+		}
 		var param DidSaveTextDocumentParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.TextDocumentDidSave(logger, &param)
+		h.TextDocumentDidSave(logger, &param)
 	case "textDocument/didClose":
+		h, ok := serv.handler.(TextDocumentSyncHandler)
+		if !ok {
+			return
+		}
 		var param DidCloseTextDocumentParams
 		if err := json.Unmarshal(req, &param); err != nil {
 			serv.errorHandler(err)
 			return
 		}
-		serv.handler.TextDocumentDidClose(logger, &param)
+		h.TextDocumentDidClose(logger, &param)
+	case "notebookDocument/didOpen":
+		h, ok := serv.handler.(NotebookDocumentSyncHandler)
+		if !ok {
+			return
+		}
+		var param DidOpenNotebookDocumentParams
+		if err := json.Unmarshal(req, &param); err != nil {
+			serv.errorHandler(err)
+			return
+		}
+		h.NotebookDocumentDidOpen(logger, &param)
+	case "notebookDocument/didChange":
+		h, ok := serv.handler.(NotebookDocumentSyncHandler)
+		if !ok {
+			return
+		}
+		var param DidChangeNotebookDocumentParams
+		if err := json.Unmarshal(req, &param); err != nil {
+			serv.errorHandler(err)
+			return
+		}
+		h.NotebookDocumentDidChange(logger, &param)
+	case "notebookDocument/didSave":
+		h, ok := serv.handler.(NotebookDocumentDidSaveHandler)
+		if !ok {
+			return
+		}
+		var param DidSaveNotebookDocumentParams
+		if err := json.Unmarshal(req, &param); err != nil {
+			serv.errorHandler(err)
+			return
+		}
+		h.NotebookDocumentDidSave(logger, &param)
+	case "notebookDocument/didClose":
+		h, ok := serv.handler.(NotebookDocumentSyncHandler)
+		if !ok {
+			return
+		}
+		var param DidCloseNotebookDocumentParams
+		if err := json.Unmarshal(req, &param); err != nil {
+			serv.errorHandler(err)
+			return
+		}
+		h.NotebookDocumentDidClose(logger, &param)
 	default:
 		if handler, ok := serv.customNotification[method]; ok {
 			handler(logger, req)
-		} else {
-			panic("unimplemented notification: " + method)
+		} else if !strings.HasPrefix(method, "$/") {
+			// Notifications starting with "$/" are protocol implementation
+			// dependent and may be safely ignored.
+			serv.errorHandler(fmt.Errorf("unimplemented notification: %s", method))
 		}
 	}
 }
 
 func (serv *Server) requestDispatcher(ctx context.Context, logger jsonrpc.FunctionLogger, method string, req json.RawMessage, respCallback func(json.RawMessage, *jsonrpc.ResponseError)) {
 	resp := func(res interface{}, err *jsonrpc.ResponseError) {
-		respCallback(EncodeMessage(res), err)
+		respCallback(encodeResult(method, res, err, serv.errorHandler))
 	}
 	resp2 := func(res1 interface{}, res2 interface{}, err *jsonrpc.ResponseError) {
 		if res1 == nil {
-			respCallback(EncodeMessage(res2), err)
+			resp(res2, err)
 		} else {
-			respCallback(EncodeMessage(res1), err)
+			resp(res1, err)
 		}
 	}
+	if err := serv.lifecycle.request(method); err != nil {
+		respCallback(nil, err.ResponseError())
+		return
+	}
 	switch method {
 	case "initialize":
 		var param InitializeParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			serv.lifecycle.initializeResult(false)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.Initialize(ctx, logger, &param))
+		if param.ProcessID != nil {
+			serv.startParentProcessWatchdog(*param.ProcessID)
+		}
+		res, err := serv.handler.Initialize(ctx, logger, &param)
+		if res != nil && serv.fillCapabilities {
+			FillServerCapabilities(&res.Capabilities, serv.handler)
+		}
+		serv.lifecycle.initializeResult(err == nil)
+		resp(res, err)
 	case "shutdown":
 		resp(nil, serv.handler.Shutdown(ctx, logger))
 	case "workspace/symbol":
+		h, ok := serv.handler.(WorkspaceSymbolProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param WorkspaceSymbolParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.WorkspaceSymbol(ctx, logger, &param))
+		resp(h.WorkspaceSymbol(ctx, logger, &param))
 	case "workspace/executeCommand":
+		h, ok := serv.handler.(ExecuteCommandProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param ExecuteCommandParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.WorkspaceExecuteCommand(ctx, logger, &param))
+		resp(h.WorkspaceExecuteCommand(ctx, logger, &param))
 	case "workspace/willCreateFiles":
+		h, ok := serv.handler.(WillCreateFilesProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param CreateFilesParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.WorkspaceWillCreateFiles(ctx, logger, &param))
+		resp(h.WorkspaceWillCreateFiles(ctx, logger, &param))
 	case "workspace/willRenameFiles":
+		h, ok := serv.handler.(WillRenameFilesProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param RenameFilesParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.WorkspaceWillRenameFiles(ctx, logger, &param))
+		resp(h.WorkspaceWillRenameFiles(ctx, logger, &param))
 	case "workspace/willDeleteFiles":
+		h, ok := serv.handler.(WillDeleteFilesProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param DeleteFilesParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.WorkspaceWillDeleteFiles(ctx, logger, &param))
+		resp(h.WorkspaceWillDeleteFiles(ctx, logger, &param))
 	case "textDocument/willSaveWaitUntil":
+		h, ok := serv.handler.(WillSaveWaitUntilProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param WillSaveTextDocumentParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentWillSaveWaitUntil(ctx, logger, &param))
+		resp(h.TextDocumentWillSaveWaitUntil(ctx, logger, &param))
 	case "textDocument/completion":
+		h, ok := serv.handler.(CompletionProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param CompletionParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentCompletion(ctx, logger, &param))
+		resp(h.TextDocumentCompletion(ctx, logger, &param))
 	case "completionItem/resolve":
+		h, ok := serv.handler.(CompletionResolveProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param CompletionItem
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.CompletionItemResolve(ctx, logger, &param))
+		resp(h.CompletionItemResolve(ctx, logger, &param))
 	case "textDocument/hover":
+		h, ok := serv.handler.(HoverProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param HoverParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentHover(ctx, logger, &param))
+		resp(h.TextDocumentHover(ctx, logger, &param))
 	case "textDocument/signatureHelp":
+		h, ok := serv.handler.(SignatureHelpProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param SignatureHelpParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentSignatureHelp(ctx, logger, &param))
+		resp(h.TextDocumentSignatureHelp(ctx, logger, &param))
 	case "textDocument/declaration":
+		h, ok := serv.handler.(DeclarationProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param DeclarationParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp2(serv.handler.TextDocumentDeclaration(ctx, logger, &param))
+		resp2(h.TextDocumentDeclaration(ctx, logger, &param))
 	case "textDocument/definition":
+		h, ok := serv.handler.(DefinitionProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param DefinitionParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp2(serv.handler.TextDocumentDefinition(ctx, logger, &param))
+		resp2(h.TextDocumentDefinition(ctx, logger, &param))
 	case "textDocument/typeDefinition":
+		h, ok := serv.handler.(TypeDefinitionProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param TypeDefinitionParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp2(serv.handler.TextDocumentTypeDefinition(ctx, logger, &param))
+		resp2(h.TextDocumentTypeDefinition(ctx, logger, &param))
 	case "textDocument/implementation":
+		h, ok := serv.handler.(ImplementationProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param ImplementationParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp2(serv.handler.TextDocumentImplementation(ctx, logger, &param))
+		resp2(h.TextDocumentImplementation(ctx, logger, &param))
 	case "textDocument/references":
+		h, ok := serv.handler.(ReferencesProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param ReferenceParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentReferences(ctx, logger, &param))
+		resp(h.TextDocumentReferences(ctx, logger, &param))
 	case "textDocument/documentHighlight":
+		h, ok := serv.handler.(DocumentHighlightProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param DocumentHighlightParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentDocumentHighlight(ctx, logger, &param))
+		resp(h.TextDocumentDocumentHighlight(ctx, logger, &param))
 	case "textDocument/documentSymbol":
+		h, ok := serv.handler.(DocumentSymbolProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param DocumentSymbolParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp2(serv.handler.TextDocumentDocumentSymbol(ctx, logger, &param))
+		resp2(h.TextDocumentDocumentSymbol(ctx, logger, &param))
 	case "textDocument/codeAction":
+		h, ok := serv.handler.(CodeActionProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param CodeActionParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentCodeAction(ctx, logger, &param))
+		resp(h.TextDocumentCodeAction(ctx, logger, &param))
 	case "codeAction/resolve":
+		h, ok := serv.handler.(CodeActionResolveProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param CodeAction
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.CodeActionResolve(ctx, logger, &param))
+		resp(h.CodeActionResolve(ctx, logger, &param))
 	case "textDocument/codeLens":
+		h, ok := serv.handler.(CodeLensProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param CodeLensParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentCodeLens(ctx, logger, &param))
+		resp(h.TextDocumentCodeLens(ctx, logger, &param))
 	case "codeLens/resolve":
+		h, ok := serv.handler.(CodeLensResolveProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param CodeLens
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.CodeLensResolve(ctx, logger, &param))
+		resp(h.CodeLensResolve(ctx, logger, &param))
 	case "textDocument/documentLink":
+		h, ok := serv.handler.(DocumentLinkProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param DocumentLinkParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentDocumentLink(ctx, logger, &param))
+		resp(h.TextDocumentDocumentLink(ctx, logger, &param))
 	case "documentLink/resolve":
+		h, ok := serv.handler.(DocumentLinkResolveProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param DocumentLink
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.DocumentLinkResolve(ctx, logger, &param))
+		resp(h.DocumentLinkResolve(ctx, logger, &param))
 	case "textDocument/documentColor":
+		h, ok := serv.handler.(ColorProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param DocumentColorParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentDocumentColor(ctx, logger, &param))
+		resp(h.TextDocumentDocumentColor(ctx, logger, &param))
 	case "textDocument/colorPresentation":
+		h, ok := serv.handler.(ColorProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param ColorPresentationParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentColorPresentation(ctx, logger, &param))
+		resp(h.TextDocumentColorPresentation(ctx, logger, &param))
 	case "textDocument/formatting":
+		h, ok := serv.handler.(DocumentFormattingProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param DocumentFormattingParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentFormatting(ctx, logger, &param))
+		resp(h.TextDocumentFormatting(ctx, logger, &param))
 	case "textDocument/rangeFormatting":
+		h, ok := serv.handler.(DocumentRangeFormattingProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param DocumentRangeFormattingParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentRangeFormatting(ctx, logger, &param))
+		resp(h.TextDocumentRangeFormatting(ctx, logger, &param))
 	case "textDocument/onTypeFormatting":
+		h, ok := serv.handler.(DocumentOnTypeFormattingProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param DocumentOnTypeFormattingParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentOnTypeFormatting(ctx, logger, &param))
+		resp(h.TextDocumentOnTypeFormatting(ctx, logger, &param))
 	case "textDocument/rename":
+		h, ok := serv.handler.(RenameProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param RenameParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentRename(ctx, logger, &param))
+		resp(h.TextDocumentRename(ctx, logger, &param))
 	case "textDocument/prepareRename":
 		var param PrepareRenameParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
 		// resp(serv.handler.TextDocumentPrepareRename(ctx,logger, &param))
-		panic("unimplemented")
+		respCallback(nil, methodNotFoundError(method))
 	case "textDocument/foldingRange":
+		h, ok := serv.handler.(FoldingRangeProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param FoldingRangeParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentFoldingRange(ctx, logger, &param))
+		resp(h.TextDocumentFoldingRange(ctx, logger, &param))
 	case "textDocument/selectionRange":
+		h, ok := serv.handler.(SelectionRangeProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param SelectionRangeParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentSelectionRange(ctx, logger, &param))
+		resp(h.TextDocumentSelectionRange(ctx, logger, &param))
 	case "textDocument/prepareCallHierarchy":
+		h, ok := serv.handler.(CallHierarchyProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param CallHierarchyPrepareParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentPrepareCallHierarchy(ctx, logger, &param))
+		resp(h.TextDocumentPrepareCallHierarchy(ctx, logger, &param))
 	case "callHierarchy/incomingCalls":
+		h, ok := serv.handler.(CallHierarchyProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param CallHierarchyIncomingCallsParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.CallHierarchyIncomingCalls(ctx, logger, &param))
+		resp(h.CallHierarchyIncomingCalls(ctx, logger, &param))
 	case "callHierarchy/outgoingCalls":
+		h, ok := serv.handler.(CallHierarchyProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param CallHierarchyOutgoingCallsParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.CallHierarchyOutgoingCalls(ctx, logger, &param))
+		resp(h.CallHierarchyOutgoingCalls(ctx, logger, &param))
+	case "textDocument/prepareTypeHierarchy":
+		h, ok := serv.handler.(TypeHierarchyProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
+		var param TypeHierarchyPrepareParams
+		if err := json.Unmarshal(req, &param); err != nil {
+			respCallback(nil, invalidParamsError(err))
+			return
+		}
+		resp(h.TextDocumentPrepareTypeHierarchy(ctx, logger, &param))
+	case "typeHierarchy/supertypes":
+		h, ok := serv.handler.(TypeHierarchyProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
+		var param TypeHierarchySupertypesParams
+		if err := json.Unmarshal(req, &param); err != nil {
+			respCallback(nil, invalidParamsError(err))
+			return
+		}
+		resp(h.TypeHierarchySupertypes(ctx, logger, &param))
+	case "typeHierarchy/subtypes":
+		h, ok := serv.handler.(TypeHierarchyProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
+		var param TypeHierarchySubtypesParams
+		if err := json.Unmarshal(req, &param); err != nil {
+			respCallback(nil, invalidParamsError(err))
+			return
+		}
+		resp(h.TypeHierarchySubtypes(ctx, logger, &param))
 	case "textDocument/semanticTokens/full":
+		h, ok := serv.handler.(SemanticTokensProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param SemanticTokensParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentSemanticTokensFull(ctx, logger, &param))
+		resp(h.TextDocumentSemanticTokensFull(ctx, logger, &param))
 	case "textDocument/semanticTokens/full/delta":
+		h, ok := serv.handler.(SemanticTokensDeltaProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param SemanticTokensDeltaParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp2(serv.handler.TextDocumentSemanticTokensFullDelta(ctx, logger, &param))
+		resp2(h.TextDocumentSemanticTokensFullDelta(ctx, logger, &param))
 	case "textDocument/semanticTokens/range":
+		h, ok := serv.handler.(SemanticTokensRangeProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param SemanticTokensRangeParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentSemanticTokensRange(ctx, logger, &param))
+		resp(h.TextDocumentSemanticTokensRange(ctx, logger, &param))
 	case "workspace/semanticTokens/refresh":
-		resp(nil, serv.handler.WorkspaceSemanticTokensRefresh(ctx, logger))
+		h, ok := serv.handler.(SemanticTokensRefreshHandler)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
+		resp(nil, h.WorkspaceSemanticTokensRefresh(ctx, logger))
 	case "textDocument/linkedEditingRange":
+		h, ok := serv.handler.(LinkedEditingRangeProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param LinkedEditingRangeParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentLinkedEditingRange(ctx, logger, &param))
+		resp(h.TextDocumentLinkedEditingRange(ctx, logger, &param))
 	case "textDocument/moniker":
+		h, ok := serv.handler.(MonikerProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
 		var param MonikerParams
 		if err := json.Unmarshal(req, &param); err != nil {
-			serv.errorHandler(err)
+			respCallback(nil, invalidParamsError(err))
+			return
+		}
+		resp(h.TextDocumentMoniker(ctx, logger, &param))
+	case "textDocument/inlineValue":
+		h, ok := serv.handler.(InlineValueProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
+		var param InlineValueParams
+		if err := json.Unmarshal(req, &param); err != nil {
+			respCallback(nil, invalidParamsError(err))
+			return
+		}
+		resp(h.TextDocumentInlineValue(ctx, logger, &param))
+	case "textDocument/inlayHint":
+		h, ok := serv.handler.(InlayHintProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
+		var param InlayHintParams
+		if err := json.Unmarshal(req, &param); err != nil {
+			respCallback(nil, invalidParamsError(err))
 			return
 		}
-		resp(serv.handler.TextDocumentMoniker(ctx, logger, &param))
+		resp(h.TextDocumentInlayHint(ctx, logger, &param))
+	case "inlayHint/resolve":
+		h, ok := serv.handler.(InlayHintResolveProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
+		var param InlayHint
+		if err := json.Unmarshal(req, &param); err != nil {
+			respCallback(nil, invalidParamsError(err))
+			return
+		}
+		resp(h.InlayHintResolve(ctx, logger, &param))
+	case "textDocument/diagnostic":
+		h, ok := serv.handler.(DocumentDiagnosticProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
+		var param DocumentDiagnosticParams
+		if err := json.Unmarshal(req, &param); err != nil {
+			respCallback(nil, invalidParamsError(err))
+			return
+		}
+		resp(h.TextDocumentDiagnostic(ctx, logger, &param))
+	case "workspace/diagnostic":
+		h, ok := serv.handler.(WorkspaceDiagnosticProvider)
+		if !ok {
+			respCallback(nil, methodNotFoundError(method))
+			return
+		}
+		var param WorkspaceDiagnosticParams
+		if err := json.Unmarshal(req, &param); err != nil {
+			respCallback(nil, invalidParamsError(err))
+			return
+		}
+		resp(h.WorkspaceDiagnostic(ctx, logger, &param))
 	default:
 		if handler, ok := serv.customRequest[method]; ok {
 			resp(handler(ctx, logger, req))
 		} else {
-			panic("unimplemented request: " + method)
+			respCallback(nil, methodNotFoundError(method))
 		}
 	}
 }
@@ -570,7 +1056,7 @@ func (serv *Server) requestDispatcher(ctx context.Context, logger jsonrpc.Functi
 // Requests to Client
 
 func (serv *Server) WindowShowMessageRequest(ctx context.Context, param *ShowMessageRequestParams) (*MessageActionItem, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := serv.conn.SendRequest(ctx, "window/showMessageRequest", EncodeMessage(param))
+	resp, respErr, err := serv.sendRequest(ctx, "window/showMessageRequest", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -583,7 +1069,7 @@ func (serv *Server) WindowShowMessageRequest(ctx context.Context, param *ShowMes
 }
 
 func (serv *Server) WindowShowDocument(ctx context.Context, param *ShowDocumentParams) (*ShowDocumentResult, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := serv.conn.SendRequest(ctx, "window/showDocument", EncodeMessage(param))
+	resp, respErr, err := serv.sendRequest(ctx, "window/showDocument", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -592,22 +1078,22 @@ func (serv *Server) WindowShowDocument(ctx context.Context, param *ShowDocumentP
 }
 
 func (serv *Server) WindowWorkDoneProgressCreate(ctx context.Context, param *WorkDoneProgressCreateParams) (*jsonrpc.ResponseError, error) {
-	_, respErr, err := serv.conn.SendRequest(ctx, "window/workDoneProgress/create", EncodeMessage(param))
+	_, respErr, err := serv.sendRequest(ctx, "window/workDoneProgress/create", param)
 	return respErr, err
 }
 
 func (serv *Server) ClientRegisterCapability(ctx context.Context, param *RegistrationParams) (*jsonrpc.ResponseError, error) {
-	_, respErr, err := serv.conn.SendRequest(ctx, "client/registerCapability", EncodeMessage(param))
+	_, respErr, err := serv.sendRequest(ctx, "client/registerCapability", param)
 	return respErr, err
 }
 
 func (serv *Server) ClientUnregisterCapability(ctx context.Context, param *UnregistrationParams) (*jsonrpc.ResponseError, error) {
-	_, respErr, err := serv.conn.SendRequest(ctx, "client/unregisterCapability", EncodeMessage(param))
+	_, respErr, err := serv.sendRequest(ctx, "client/unregisterCapability", param)
 	return respErr, err
 }
 
 func (serv *Server) WorkspaceWorkspaceFolders(ctx context.Context) ([]WorkspaceFolder, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := serv.conn.SendRequest(ctx, "workspace/workspaceFolders", EncodeMessage(jsonrpc.NullResult))
+	resp, respErr, err := serv.sendRequest(ctx, "workspace/workspaceFolders", jsonrpc.NullResult)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -620,7 +1106,7 @@ func (serv *Server) WorkspaceWorkspaceFolders(ctx context.Context) ([]WorkspaceF
 }
 
 func (serv *Server) WorkspaceConfiguration(ctx context.Context, param *ConfigurationParams) ([]json.RawMessage, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := serv.conn.SendRequest(ctx, "workspace/configuration", EncodeMessage(param))
+	resp, respErr, err := serv.sendRequest(ctx, "workspace/configuration", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -630,7 +1116,7 @@ func (serv *Server) WorkspaceConfiguration(ctx context.Context, param *Configura
 }
 
 func (serv *Server) WorkspaceApplyEdit(ctx context.Context, param *ApplyWorkspaceEditParams) (*ApplyWorkspaceEditResult, *jsonrpc.ResponseError, error) {
-	resp, respErr, err := serv.conn.SendRequest(ctx, "workspace/applyEdit", EncodeMessage(param))
+	resp, respErr, err := serv.sendRequest(ctx, "workspace/applyEdit", param)
 	if err != nil || respErr != nil {
 		return nil, respErr, err
 	}
@@ -639,32 +1125,47 @@ func (serv *Server) WorkspaceApplyEdit(ctx context.Context, param *ApplyWorkspac
 }
 
 func (serv *Server) WorkspaceCodeLensRefresh(ctx context.Context) (*jsonrpc.ResponseError, error) {
-	_, respErr, err := serv.conn.SendRequest(ctx, "workspace/codeLens/refresh", EncodeMessage(jsonrpc.NullResult))
+	_, respErr, err := serv.sendRequest(ctx, "workspace/codeLens/refresh", jsonrpc.NullResult)
+	return respErr, err
+}
+
+func (serv *Server) WorkspaceInlineValueRefresh(ctx context.Context) (*jsonrpc.ResponseError, error) {
+	_, respErr, err := serv.sendRequest(ctx, "workspace/inlineValue/refresh", jsonrpc.NullResult)
+	return respErr, err
+}
+
+func (serv *Server) WorkspaceInlayHintRefresh(ctx context.Context) (*jsonrpc.ResponseError, error) {
+	_, respErr, err := serv.sendRequest(ctx, "workspace/inlayHint/refresh", jsonrpc.NullResult)
+	return respErr, err
+}
+
+func (serv *Server) WorkspaceDiagnosticRefresh(ctx context.Context) (*jsonrpc.ResponseError, error) {
+	_, respErr, err := serv.sendRequest(ctx, "workspace/diagnostic/refresh", jsonrpc.NullResult)
 	return respErr, err
 }
 
 // Notifications to Client
 
 func (serv *Server) Progress(param *ProgressParams) error {
-	return serv.conn.SendNotification("$/progress", EncodeMessage(param))
+	return serv.sendNotification("$/progress", param)
 }
 
 func (serv *Server) LogTrace(param *LogTraceParams) error {
-	return serv.conn.SendNotification("&/logTrace", EncodeMessage(param))
+	return serv.sendNotification("&/logTrace", param)
 }
 
 func (serv *Server) WindowShowMessage(param *ShowMessageParams) error {
-	return serv.conn.SendNotification("window/showMessage", EncodeMessage(param))
+	return serv.sendNotification("window/showMessage", param)
 }
 
 func (serv *Server) WindowLogMessage(param *LogMessageParams) error {
-	return serv.conn.SendNotification("window/logMessage", EncodeMessage(param))
+	return serv.sendNotification("window/logMessage", param)
 }
 
 func (serv *Server) TelemetryEvent(param json.RawMessage) error {
-	return serv.conn.SendNotification("telemetry/event", param)
+	return serv.sendNotification("telemetry/event", param)
 }
 
 func (serv *Server) TextDocumentPublishDiagnostics(param *PublishDiagnosticsParams) error {
-	return serv.conn.SendNotification("textDocument/publishDiagnostics", EncodeMessage(param))
+	return serv.sendNotification("textDocument/publishDiagnostics", param)
 }
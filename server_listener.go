@@ -0,0 +1,51 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"net"
+)
+
+// ServerHandlerFactory creates the handler for a new Server. The Server is
+// given to the factory to allow the handler to send messages to the client,
+// and to configure the Server (logger, error handler, etc.) before it runs.
+type ServerHandlerFactory func(serv *Server) ClientMessagesHandler
+
+// ListenAndServe listens on the given network address ("tcp", "unix", etc.)
+// and serves a new Server for each accepted connection, see Serve.
+func ListenAndServe(network, addr string, factory ServerHandlerFactory, opts ...ConnectionOption) error {
+	listener, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	return Serve(listener, factory, opts...)
+}
+
+// Serve accepts the incoming connections on the listener and runs a new
+// Server, with a fresh handler obtained from the factory, for each one.
+// Each Server runs in its own goroutine until the client disconnects or
+// sends the exit notification; the handlers must not terminate the process
+// on exit, since the other clients are still served.
+// Serve always returns a non-nil error, that is the error returned by
+// the listener Accept (net.ErrClosed if the listener has been closed).
+func Serve(listener net.Listener, factory ServerHandlerFactory, opts ...ConnectionOption) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go serveConn(conn, factory, opts)
+	}
+}
+
+func serveConn(conn net.Conn, factory ServerHandlerFactory, opts []ConnectionOption) {
+	defer conn.Close()
+	serv := NewServer(conn, conn, nil, opts...)
+	serv.handler = factory(serv)
+	serv.Run()
+}
@@ -0,0 +1,84 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.bug.st/json"
+	"go.bug.st/lsp/jsonrpc"
+)
+
+// exitHandler is a ClientMessagesHandler that implements only Exit.
+type exitHandler struct {
+	UnimplementedClientMessagesHandler
+	exited chan struct{}
+}
+
+func (h *exitHandler) Exit(logger jsonrpc.FunctionLogger) {
+	close(h.exited)
+}
+
+func TestServe(t *testing.T) {
+	for _, network := range []string{"tcp", "unix"} {
+		t.Run(network, func(t *testing.T) {
+			addr := "127.0.0.1:0"
+			if network == "unix" {
+				addr = filepath.Join(t.TempDir(), "lsp.sock")
+			}
+			listener, err := net.Listen(network, addr)
+			require.NoError(t, err)
+
+			var handlers atomic.Int32
+			exited := make(chan struct{})
+			serveErr := make(chan error, 1)
+			go func() {
+				serveErr <- Serve(listener, func(serv *Server) ClientMessagesHandler {
+					handlers.Add(1)
+					return &exitHandler{exited: exited}
+				})
+			}()
+
+			dial := func() *jsonrpc.Connection {
+				netConn, err := net.Dial(network, listener.Addr().String())
+				require.NoError(t, err)
+				conn := jsonrpc.NewConnection(netConn, netConn, nil, nil, func(e error) {})
+				go conn.Run()
+				return conn
+			}
+			conn1 := dial()
+			conn2 := dial()
+			defer conn2.Close()
+
+			// The first client exits: its server is terminated...
+			require.NoError(t, conn1.SendNotification("exit", json.RawMessage(`{}`)))
+			select {
+			case <-exited:
+			case <-time.After(5 * time.Second):
+				t.Fatal("exit not received")
+			}
+			_, _, err = conn1.SendRequest(context.Background(), "unknown/method", json.RawMessage(`{}`))
+			require.Error(t, err)
+
+			// ...while the second is still served.
+			_, resErr, err := conn2.SendRequest(context.Background(), "unknown/method", json.RawMessage(`{}`))
+			require.NoError(t, err)
+			require.Equal(t, jsonrpc.ErrorCodesMethodNotFound, resErr.Code)
+			require.Equal(t, int32(2), handlers.Load())
+
+			listener.Close()
+			require.True(t, errors.Is(<-serveErr, net.ErrClosed))
+		})
+	}
+}
@@ -0,0 +1,174 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.bug.st/json"
+)
+
+// serveArgs are the transport arguments passed to a language server by
+// the clients (vscode-languageclient conventions).
+type serveArgs struct {
+	stdio           bool
+	socketPort      int
+	pipeName        string
+	clientProcessID int
+}
+
+// parseServeArgs extracts the transport arguments from args, any other
+// argument is ignored. If no transport is specified stdio is used.
+func parseServeArgs(args []string) (*serveArgs, error) {
+	res := &serveArgs{}
+	transports := 0
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "--stdio":
+			res.stdio = true
+			transports++
+		case "--socket":
+			port, err := strconv.Atoi(value)
+			if !hasValue || err != nil || port <= 0 || port > 65535 {
+				return nil, fmt.Errorf("invalid socket port: %s", arg)
+			}
+			res.socketPort = port
+			transports++
+		case "--pipe":
+			if value == "" {
+				return nil, fmt.Errorf("invalid pipe name: %s", arg)
+			}
+			res.pipeName = value
+			transports++
+		case "--clientProcessId":
+			pid, err := strconv.Atoi(value)
+			if !hasValue || err != nil || pid <= 0 {
+				return nil, fmt.Errorf("invalid client process id: %s", arg)
+			}
+			res.clientProcessID = pid
+		}
+	}
+	if transports > 1 {
+		return nil, fmt.Errorf("only one of --stdio, --socket and --pipe can be specified")
+	}
+	if transports == 0 {
+		res.stdio = true
+	}
+	return res, nil
+}
+
+// ServeMain is a ready-made entry point for a language server. It parses the
+// command line arguments passed by the clients, connects the transport and
+// runs a Server with the handler obtained from the factory, until the
+// connection is closed. args are the command line arguments without the
+// program name (usually os.Args[1:]), the supported transports are:
+//
+//	--stdio             use stdin and stdout (the default)
+//	--socket=PORT       connect to the TCP port PORT on the local host
+//	--pipe=NAME         connect to the Unix domain socket NAME
+//
+// The --clientProcessId=PID argument is also accepted: if the factory enables
+// the parent process watchdog (see Server.SetParentProcessWatchdog) the
+// process PID is monitored from the start. Any other argument is ignored.
+//
+// With the stdio transport the os.Stdout variable is set to os.Stderr while
+// the server runs, so that stray prints through os.Stdout (fmt.Print, etc.)
+// don't corrupt the protocol stream. Only the variable is changed, the file
+// descriptor 1 still carries the protocol: anything writing to it directly
+// (cgo code, child processes inheriting it, writers that captured os.Stdout
+// before the call, such as a log.Logger) still corrupts the stream.
+//
+// When a SIGINT or SIGTERM is received, the handler Shutdown and Exit
+// methods are called as if the client had requested them.
+//
+// An error is returned if the session ends without the shutdown request
+// followed by the exit notification.
+func ServeMain(args []string, factory ServerHandlerFactory, opts ...ConnectionOption) error {
+	serveArgs, err := parseServeArgs(args)
+	if err != nil {
+		return err
+	}
+
+	var in io.Reader
+	var out io.Writer
+	switch {
+	case serveArgs.stdio:
+		stdout := os.Stdout
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = stdout }()
+		in, out = os.Stdin, stdout
+	case serveArgs.socketPort != 0:
+		conn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(serveArgs.socketPort)))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		in, out = conn, conn
+	case serveArgs.pipeName != "":
+		conn, err := net.Dial("unix", serveArgs.pipeName)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		in, out = conn, conn
+	}
+
+	serv := NewServer(in, out, nil, opts...)
+	serv.handler = factory(serv)
+	if serveArgs.clientProcessID != 0 {
+		serv.startParentProcessWatchdog(serveArgs.clientProcessID)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-signals:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			serv.shutdownAndExit(ctx)
+		case <-done:
+		}
+	}()
+
+	serv.Run()
+	if !serv.lifecycle.exitedCleanly() {
+		return fmt.Errorf("session ended without shutdown and exit (state: %s)", serv.LifecycleState())
+	}
+	return nil
+}
+
+// shutdownAndExit runs the shutdown request and the exit notification, as if
+// they were sent by the client. The messages are dispatched through the
+// connection, serialized with the messages received from the client. The
+// shutdown request is skipped if the session has not been initialized or is
+// already shutting down.
+func (serv *Server) shutdownAndExit(ctx context.Context) {
+	if state := serv.LifecycleState(); state == LifecycleInitializing || state == LifecycleRunning {
+		_, resErr, err := serv.conn.DispatchLocalRequest(ctx, "shutdown", json.RawMessage("null"))
+		if err == nil && resErr != nil {
+			err = resErr.AsError()
+		}
+		if err != nil {
+			serv.errorHandler(fmt.Errorf("shutdown failed: %w", err))
+		}
+	}
+	serv.conn.DispatchLocalNotification("exit", json.RawMessage("null"))
+}
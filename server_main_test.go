@@ -0,0 +1,172 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.bug.st/json"
+	"go.bug.st/lsp/jsonrpc"
+)
+
+func TestParseServeArgs(t *testing.T) {
+	args, err := parseServeArgs([]string{"--verbose"})
+	require.NoError(t, err)
+	require.Equal(t, &serveArgs{stdio: true}, args)
+
+	args, err = parseServeArgs([]string{"--socket=5007", "--clientProcessId=1234"})
+	require.NoError(t, err)
+	require.Equal(t, &serveArgs{socketPort: 5007, clientProcessID: 1234}, args)
+
+	args, err = parseServeArgs([]string{"--pipe=/tmp/lsp.sock"})
+	require.NoError(t, err)
+	require.Equal(t, &serveArgs{pipeName: "/tmp/lsp.sock"}, args)
+
+	for _, invalid := range [][]string{
+		{"--socket"},
+		{"--socket=abc"},
+		{"--socket=70000"},
+		{"--pipe="},
+		{"--clientProcessId=x"},
+		{"--stdio", "--socket=5007"},
+	} {
+		_, err := parseServeArgs(invalid)
+		require.Error(t, err, "args: %v", invalid)
+	}
+}
+
+func TestServeMain(t *testing.T) {
+	runServeMain := func(t *testing.T, listener net.Listener, clean bool, args ...string) {
+		handler := &lifecycleHandler{}
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- ServeMain(args, func(serv *Server) ClientMessagesHandler { return handler })
+		}()
+
+		netConn, err := listener.Accept()
+		require.NoError(t, err)
+		conn := jsonrpc.NewConnection(netConn, netConn, nil, nil, func(e error) {})
+		go conn.Run()
+		defer conn.Close()
+
+		_, resErr, err := conn.SendRequest(context.Background(), "unknown/method", json.RawMessage(`{}`))
+		require.NoError(t, err)
+		require.Equal(t, jsonrpc.ErrorCodesMethodNotFound, resErr.Code)
+		if clean {
+			_, resErr, err := conn.SendRequest(context.Background(), "shutdown", nil)
+			require.NoError(t, err)
+			require.Nil(t, resErr)
+		}
+		require.NoError(t, conn.SendNotification("exit", json.RawMessage(`{}`)))
+
+		select {
+		case err := <-serveErr:
+			if clean {
+				require.NoError(t, err)
+				require.Equal(t, []string{"shutdown", "exit"}, handler.calls)
+			} else {
+				require.EqualError(t, err, "session ended without shutdown and exit (state: exited)")
+				require.Equal(t, []string{"exit"}, handler.calls)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("ServeMain not terminated")
+		}
+	}
+
+	t.Run("socket", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		port := listener.Addr().(*net.TCPAddr).Port
+		runServeMain(t, listener, true, "--socket="+strconv.Itoa(port), "--clientProcessId=1")
+	})
+
+	t.Run("pipe", func(t *testing.T) {
+		pipeName := filepath.Join(t.TempDir(), "lsp.sock")
+		listener, err := net.Listen("unix", pipeName)
+		require.NoError(t, err)
+		defer listener.Close()
+		runServeMain(t, listener, true, "--pipe="+pipeName)
+	})
+
+	t.Run("exit without shutdown", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer listener.Close()
+		port := listener.Addr().(*net.TCPAddr).Port
+		runServeMain(t, listener, false, "--socket="+strconv.Itoa(port))
+	})
+}
+
+func TestServerShutdownAndExit(t *testing.T) {
+	// A session never initialized doesn't need the shutdown
+	handler := &lifecycleHandler{}
+	serv := NewServer(strings.NewReader(""), &bytes.Buffer{}, handler)
+	serv.shutdownAndExit(context.Background())
+	require.Equal(t, []string{"exit"}, handler.calls)
+	require.True(t, serv.conn.IsClosed())
+
+	handler = &lifecycleHandler{}
+	serv = NewServer(strings.NewReader(""), &bytes.Buffer{}, handler)
+	serv.SetLifecycleGuard(true)
+	require.Nil(t, serv.lifecycle.request("initialize"))
+	serv.lifecycle.initializeResult(true)
+	require.Nil(t, serv.lifecycle.notification("initialized"))
+	require.Equal(t, LifecycleRunning, serv.LifecycleState())
+	serv.shutdownAndExit(context.Background())
+	require.Equal(t, []string{"shutdown", "exit"}, handler.calls)
+	require.Equal(t, LifecycleExited, serv.LifecycleState())
+}
+
+// blockingShutdownHandler blocks the initialize request until released.
+type blockingShutdownHandler struct {
+	lifecycleHandler
+	started, release chan struct{}
+}
+
+func (h *blockingShutdownHandler) Initialize(ctx context.Context, logger jsonrpc.FunctionLogger, params *InitializeParams) (*InitializeResult, *jsonrpc.ResponseError) {
+	close(h.started)
+	<-h.release
+	return h.lifecycleHandler.Initialize(ctx, logger, params)
+}
+
+func TestServerShutdownAndExitSerialized(t *testing.T) {
+	// The synthetic shutdown must wait for the request being handled
+	// synchronously in the read loop
+	input := encodeFrames(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"processId":null,"rootUri":null,"capabilities":{}}}`)
+	inR, inW := io.Pipe()
+	defer inW.Close()
+	handler := &blockingShutdownHandler{started: make(chan struct{}), release: make(chan struct{})}
+	serv := NewServer(inR, io.Discard, handler)
+	go serv.Run()
+	go inW.Write([]byte(input))
+	<-handler.started
+
+	done := make(chan struct{})
+	go func() {
+		serv.shutdownAndExit(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("shutdown dispatched while a request was being handled")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(handler.release)
+	<-done
+	require.Equal(t, []string{"initialize", "shutdown", "exit"}, handler.calls)
+	require.True(t, serv.lifecycle.exitedCleanly())
+}
@@ -0,0 +1,404 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"context"
+
+	"go.bug.st/json"
+	"go.bug.st/lsp/jsonrpc"
+)
+
+// The interfaces below may be implemented by a ClientMessagesHandler to
+// support the corresponding features. The Server routes the messages only to
+// the implemented interfaces (the other requests are answered with a
+// MethodNotFound error, the other notifications are ignored) and, if enabled
+// with Server.SetFillServerCapabilities, advertises the missing capabilities
+// accordingly, see FillServerCapabilities.
+
+// Request -> Response
+
+// WorkspaceSymbolProvider is implemented by the handlers that support the workspace/symbol request.
+type WorkspaceSymbolProvider interface {
+	WorkspaceSymbol(context.Context, jsonrpc.FunctionLogger, *WorkspaceSymbolParams) ([]SymbolInformation, *jsonrpc.ResponseError)
+}
+
+// ExecuteCommandProvider is implemented by the handlers that support the workspace/executeCommand request.
+type ExecuteCommandProvider interface {
+	WorkspaceExecuteCommand(context.Context, jsonrpc.FunctionLogger, *ExecuteCommandParams) (json.RawMessage, *jsonrpc.ResponseError)
+}
+
+// WillCreateFilesProvider is implemented by the handlers that support the workspace/willCreateFiles request.
+type WillCreateFilesProvider interface {
+	WorkspaceWillCreateFiles(context.Context, jsonrpc.FunctionLogger, *CreateFilesParams) (*WorkspaceEdit, *jsonrpc.ResponseError)
+}
+
+// WillRenameFilesProvider is implemented by the handlers that support the workspace/willRenameFiles request.
+type WillRenameFilesProvider interface {
+	WorkspaceWillRenameFiles(context.Context, jsonrpc.FunctionLogger, *RenameFilesParams) (*WorkspaceEdit, *jsonrpc.ResponseError)
+}
+
+// WillDeleteFilesProvider is implemented by the handlers that support the workspace/willDeleteFiles request.
+type WillDeleteFilesProvider interface {
+	WorkspaceWillDeleteFiles(context.Context, jsonrpc.FunctionLogger, *DeleteFilesParams) (*WorkspaceEdit, *jsonrpc.ResponseError)
+}
+
+// WillSaveWaitUntilProvider is implemented by the handlers that support the textDocument/willSaveWaitUntil request.
+type WillSaveWaitUntilProvider interface {
+	TextDocumentWillSaveWaitUntil(context.Context, jsonrpc.FunctionLogger, *WillSaveTextDocumentParams) ([]TextEdit, *jsonrpc.ResponseError)
+}
+
+// CompletionProvider is implemented by the handlers that support the textDocument/completion request.
+type CompletionProvider interface {
+	TextDocumentCompletion(context.Context, jsonrpc.FunctionLogger, *CompletionParams) (*CompletionList, *jsonrpc.ResponseError)
+}
+
+// CompletionResolveProvider is implemented by the handlers that support the completionItem/resolve request.
+type CompletionResolveProvider interface {
+	CompletionItemResolve(context.Context, jsonrpc.FunctionLogger, *CompletionItem) (*CompletionItem, *jsonrpc.ResponseError)
+}
+
+// HoverProvider is implemented by the handlers that support the textDocument/hover request.
+type HoverProvider interface {
+	TextDocumentHover(context.Context, jsonrpc.FunctionLogger, *HoverParams) (*Hover, *jsonrpc.ResponseError)
+}
+
+// SignatureHelpProvider is implemented by the handlers that support the textDocument/signatureHelp request.
+type SignatureHelpProvider interface {
+	TextDocumentSignatureHelp(context.Context, jsonrpc.FunctionLogger, *SignatureHelpParams) (*SignatureHelp, *jsonrpc.ResponseError)
+}
+
+// DeclarationProvider is implemented by the handlers that support the textDocument/declaration request.
+type DeclarationProvider interface {
+	TextDocumentDeclaration(context.Context, jsonrpc.FunctionLogger, *DeclarationParams) ([]Location, []LocationLink, *jsonrpc.ResponseError)
+}
+
+// DefinitionProvider is implemented by the handlers that support the textDocument/definition request.
+type DefinitionProvider interface {
+	TextDocumentDefinition(context.Context, jsonrpc.FunctionLogger, *DefinitionParams) ([]Location, []LocationLink, *jsonrpc.ResponseError)
+}
+
+// TypeDefinitionProvider is implemented by the handlers that support the textDocument/typeDefinition request.
+type TypeDefinitionProvider interface {
+	TextDocumentTypeDefinition(context.Context, jsonrpc.FunctionLogger, *TypeDefinitionParams) ([]Location, []LocationLink, *jsonrpc.ResponseError)
+}
+
+// ImplementationProvider is implemented by the handlers that support the textDocument/implementation request.
+type ImplementationProvider interface {
+	TextDocumentImplementation(context.Context, jsonrpc.FunctionLogger, *ImplementationParams) ([]Location, []LocationLink, *jsonrpc.ResponseError)
+}
+
+// ReferencesProvider is implemented by the handlers that support the textDocument/references request.
+type ReferencesProvider interface {
+	TextDocumentReferences(context.Context, jsonrpc.FunctionLogger, *ReferenceParams) ([]Location, *jsonrpc.ResponseError)
+}
+
+// DocumentHighlightProvider is implemented by the handlers that support the textDocument/documentHighlight request.
+type DocumentHighlightProvider interface {
+	TextDocumentDocumentHighlight(context.Context, jsonrpc.FunctionLogger, *DocumentHighlightParams) ([]DocumentHighlight, *jsonrpc.ResponseError)
+}
+
+// DocumentSymbolProvider is implemented by the handlers that support the textDocument/documentSymbol request.
+type DocumentSymbolProvider interface {
+	TextDocumentDocumentSymbol(context.Context, jsonrpc.FunctionLogger, *DocumentSymbolParams) ([]DocumentSymbol, []SymbolInformation, *jsonrpc.ResponseError)
+}
+
+// CodeActionProvider is implemented by the handlers that support the textDocument/codeAction request.
+type CodeActionProvider interface {
+	TextDocumentCodeAction(context.Context, jsonrpc.FunctionLogger, *CodeActionParams) ([]CommandOrCodeAction, *jsonrpc.ResponseError)
+}
+
+// CodeActionResolveProvider is implemented by the handlers that support the codeAction/resolve request.
+type CodeActionResolveProvider interface {
+	CodeActionResolve(context.Context, jsonrpc.FunctionLogger, *CodeAction) (*CodeAction, *jsonrpc.ResponseError)
+}
+
+// CodeLensProvider is implemented by the handlers that support the textDocument/codeLens request.
+type CodeLensProvider interface {
+	TextDocumentCodeLens(context.Context, jsonrpc.FunctionLogger, *CodeLensParams) ([]CodeLens, *jsonrpc.ResponseError)
+}
+
+// CodeLensResolveProvider is implemented by the handlers that support the codeLens/resolve request.
+type CodeLensResolveProvider interface {
+	CodeLensResolve(context.Context, jsonrpc.FunctionLogger, *CodeLens) (*CodeLens, *jsonrpc.ResponseError)
+}
+
+// DocumentLinkProvider is implemented by the handlers that support the textDocument/documentLink request.
+type DocumentLinkProvider interface {
+	TextDocumentDocumentLink(context.Context, jsonrpc.FunctionLogger, *DocumentLinkParams) ([]DocumentLink, *jsonrpc.ResponseError)
+}
+
+// DocumentLinkResolveProvider is implemented by the handlers that support the documentLink/resolve request.
+type DocumentLinkResolveProvider interface {
+	DocumentLinkResolve(context.Context, jsonrpc.FunctionLogger, *DocumentLink) (*DocumentLink, *jsonrpc.ResponseError)
+}
+
+// ColorProvider is implemented by the handlers that support the textDocument/documentColor and textDocument/colorPresentation requests.
+type ColorProvider interface {
+	TextDocumentDocumentColor(context.Context, jsonrpc.FunctionLogger, *DocumentColorParams) ([]ColorInformation, *jsonrpc.ResponseError)
+	TextDocumentColorPresentation(context.Context, jsonrpc.FunctionLogger, *ColorPresentationParams) ([]ColorPresentation, *jsonrpc.ResponseError)
+}
+
+// DocumentFormattingProvider is implemented by the handlers that support the textDocument/formatting request.
+type DocumentFormattingProvider interface {
+	TextDocumentFormatting(context.Context, jsonrpc.FunctionLogger, *DocumentFormattingParams) ([]TextEdit, *jsonrpc.ResponseError)
+}
+
+// DocumentRangeFormattingProvider is implemented by the handlers that support the textDocument/rangeFormatting request.
+type DocumentRangeFormattingProvider interface {
+	TextDocumentRangeFormatting(context.Context, jsonrpc.FunctionLogger, *DocumentRangeFormattingParams) ([]TextEdit, *jsonrpc.ResponseError)
+}
+
+// DocumentOnTypeFormattingProvider is implemented by the handlers that support the textDocument/onTypeFormatting request.
+type DocumentOnTypeFormattingProvider interface {
+	TextDocumentOnTypeFormatting(context.Context, jsonrpc.FunctionLogger, *DocumentOnTypeFormattingParams) ([]TextEdit, *jsonrpc.ResponseError)
+}
+
+// RenameProvider is implemented by the handlers that support the textDocument/rename request.
+type RenameProvider interface {
+	TextDocumentRename(context.Context, jsonrpc.FunctionLogger, *RenameParams) (*WorkspaceEdit, *jsonrpc.ResponseError)
+}
+
+// FoldingRangeProvider is implemented by the handlers that support the textDocument/foldingRange request.
+type FoldingRangeProvider interface {
+	TextDocumentFoldingRange(context.Context, jsonrpc.FunctionLogger, *FoldingRangeParams) ([]FoldingRange, *jsonrpc.ResponseError)
+}
+
+// SelectionRangeProvider is implemented by the handlers that support the textDocument/selectionRange request.
+type SelectionRangeProvider interface {
+	TextDocumentSelectionRange(context.Context, jsonrpc.FunctionLogger, *SelectionRangeParams) ([]SelectionRange, *jsonrpc.ResponseError)
+}
+
+// CallHierarchyProvider is implemented by the handlers that support the call hierarchy requests.
+type CallHierarchyProvider interface {
+	TextDocumentPrepareCallHierarchy(context.Context, jsonrpc.FunctionLogger, *CallHierarchyPrepareParams) ([]CallHierarchyItem, *jsonrpc.ResponseError)
+	CallHierarchyIncomingCalls(context.Context, jsonrpc.FunctionLogger, *CallHierarchyIncomingCallsParams) ([]CallHierarchyIncomingCall, *jsonrpc.ResponseError)
+	CallHierarchyOutgoingCalls(context.Context, jsonrpc.FunctionLogger, *CallHierarchyOutgoingCallsParams) ([]CallHierarchyOutgoingCall, *jsonrpc.ResponseError)
+}
+
+// TypeHierarchyProvider is implemented by the handlers that support the type hierarchy requests.
+type TypeHierarchyProvider interface {
+	TextDocumentPrepareTypeHierarchy(context.Context, jsonrpc.FunctionLogger, *TypeHierarchyPrepareParams) ([]TypeHierarchyItem, *jsonrpc.ResponseError)
+	TypeHierarchySupertypes(context.Context, jsonrpc.FunctionLogger, *TypeHierarchySupertypesParams) ([]TypeHierarchyItem, *jsonrpc.ResponseError)
+	TypeHierarchySubtypes(context.Context, jsonrpc.FunctionLogger, *TypeHierarchySubtypesParams) ([]TypeHierarchyItem, *jsonrpc.ResponseError)
+}
+
+// SemanticTokensProvider is implemented by the handlers that support the textDocument/semanticTokens/full request.
+type SemanticTokensProvider interface {
+	TextDocumentSemanticTokensFull(context.Context, jsonrpc.FunctionLogger, *SemanticTokensParams) (*SemanticTokens, *jsonrpc.ResponseError)
+}
+
+// SemanticTokensDeltaProvider is implemented by the handlers that support the textDocument/semanticTokens/full/delta request.
+type SemanticTokensDeltaProvider interface {
+	TextDocumentSemanticTokensFullDelta(context.Context, jsonrpc.FunctionLogger, *SemanticTokensDeltaParams) (*SemanticTokens, *SemanticTokensDelta, *jsonrpc.ResponseError)
+}
+
+// SemanticTokensRangeProvider is implemented by the handlers that support the textDocument/semanticTokens/range request.
+type SemanticTokensRangeProvider interface {
+	TextDocumentSemanticTokensRange(context.Context, jsonrpc.FunctionLogger, *SemanticTokensRangeParams) (*SemanticTokens, *jsonrpc.ResponseError)
+}
+
+// SemanticTokensRefreshHandler is implemented by the handlers that support the workspace/semanticTokens/refresh request.
+type SemanticTokensRefreshHandler interface {
+	WorkspaceSemanticTokensRefresh(context.Context, jsonrpc.FunctionLogger) *jsonrpc.ResponseError
+}
+
+// LinkedEditingRangeProvider is implemented by the handlers that support the textDocument/linkedEditingRange request.
+type LinkedEditingRangeProvider interface {
+	TextDocumentLinkedEditingRange(context.Context, jsonrpc.FunctionLogger, *LinkedEditingRangeParams) (*LinkedEditingRanges, *jsonrpc.ResponseError)
+}
+
+// MonikerProvider is implemented by the handlers that support the textDocument/moniker request.
+type MonikerProvider interface {
+	TextDocumentMoniker(context.Context, jsonrpc.FunctionLogger, *MonikerParams) ([]Moniker, *jsonrpc.ResponseError)
+}
+
+// InlineValueProvider is implemented by the handlers that support the textDocument/inlineValue request.
+type InlineValueProvider interface {
+	TextDocumentInlineValue(context.Context, jsonrpc.FunctionLogger, *InlineValueParams) ([]InlineValue, *jsonrpc.ResponseError)
+}
+
+// InlayHintProvider is implemented by the handlers that support the textDocument/inlayHint request.
+type InlayHintProvider interface {
+	TextDocumentInlayHint(context.Context, jsonrpc.FunctionLogger, *InlayHintParams) ([]InlayHint, *jsonrpc.ResponseError)
+}
+
+// InlayHintResolveProvider is implemented by the handlers that support the inlayHint/resolve request.
+type InlayHintResolveProvider interface {
+	InlayHintResolve(context.Context, jsonrpc.FunctionLogger, *InlayHint) (*InlayHint, *jsonrpc.ResponseError)
+}
+
+// DocumentDiagnosticProvider is implemented by the handlers that support the textDocument/diagnostic request.
+type DocumentDiagnosticProvider interface {
+	TextDocumentDiagnostic(context.Context, jsonrpc.FunctionLogger, *DocumentDiagnosticParams) (*DocumentDiagnosticReport, *jsonrpc.ResponseError)
+}
+
+// WorkspaceDiagnosticProvider is implemented by the handlers that support the workspace/diagnostic request.
+type WorkspaceDiagnosticProvider interface {
+	WorkspaceDiagnostic(context.Context, jsonrpc.FunctionLogger, *WorkspaceDiagnosticParams) (*WorkspaceDiagnosticReport, *jsonrpc.ResponseError)
+}
+
+// Notifications ->
+
+// ProgressHandler is implemented by the handlers that receive the $/progress notification.
+type ProgressHandler interface {
+	Progress(jsonrpc.FunctionLogger, *ProgressParams)
+}
+
+// SetTraceHandler is implemented by the handlers that receive the $/setTrace notification.
+type SetTraceHandler interface {
+	SetTrace(jsonrpc.FunctionLogger, *SetTraceParams)
+}
+
+// WorkDoneProgressCancelHandler is implemented by the handlers that receive the window/workDoneProgress/cancel notification.
+type WorkDoneProgressCancelHandler interface {
+	WindowWorkDoneProgressCancel(jsonrpc.FunctionLogger, *WorkDoneProgressCancelParams)
+}
+
+// DidChangeWorkspaceFoldersHandler is implemented by the handlers that receive the workspace/didChangeWorkspaceFolders notification.
+type DidChangeWorkspaceFoldersHandler interface {
+	WorkspaceDidChangeWorkspaceFolders(jsonrpc.FunctionLogger, *DidChangeWorkspaceFoldersParams)
+}
+
+// DidChangeConfigurationHandler is implemented by the handlers that receive the workspace/didChangeConfiguration notification.
+type DidChangeConfigurationHandler interface {
+	WorkspaceDidChangeConfiguration(jsonrpc.FunctionLogger, *DidChangeConfigurationParams)
+}
+
+// DidChangeWatchedFilesHandler is implemented by the handlers that receive the workspace/didChangeWatchedFiles notification.
+type DidChangeWatchedFilesHandler interface {
+	WorkspaceDidChangeWatchedFiles(jsonrpc.FunctionLogger, *DidChangeWatchedFilesParams)
+}
+
+// DidCreateFilesHandler is implemented by the handlers that receive the workspace/didCreateFiles notification.
+type DidCreateFilesHandler interface {
+	WorkspaceDidCreateFiles(jsonrpc.FunctionLogger, *CreateFilesParams)
+}
+
+// DidRenameFilesHandler is implemented by the handlers that receive the workspace/didRenameFiles notification.
+type DidRenameFilesHandler interface {
+	WorkspaceDidRenameFiles(jsonrpc.FunctionLogger, *RenameFilesParams)
+}
+
+// DidDeleteFilesHandler is implemented by the handlers that receive the workspace/didDeleteFiles notification.
+type DidDeleteFilesHandler interface {
+	WorkspaceDidDeleteFiles(jsonrpc.FunctionLogger, *DeleteFilesParams)
+}
+
+// TextDocumentSyncHandler is implemented by the handlers that receive the textDocument/didOpen, textDocument/didChange and textDocument/didClose notifications.
+type TextDocumentSyncHandler interface {
+	TextDocumentDidOpen(jsonrpc.FunctionLogger, *DidOpenTextDocumentParams)
+	TextDocumentDidChange(jsonrpc.FunctionLogger, *DidChangeTextDocumentParams)
+	TextDocumentDidClose(jsonrpc.FunctionLogger, *DidCloseTextDocumentParams)
+}
+
+// WillSaveHandler is implemented by the handlers that receive the textDocument/willSave notification.
+type WillSaveHandler interface {
+	TextDocumentWillSave(jsonrpc.FunctionLogger, *WillSaveTextDocumentParams)
+}
+
+// DidSaveHandler is implemented by the handlers that receive the textDocument/didSave notification.
+type DidSaveHandler interface {
+	TextDocumentDidSave(jsonrpc.FunctionLogger, *DidSaveTextDocumentParams)
+}
+
+// NotebookDocumentSyncHandler is implemented by the handlers that receive the notebookDocument/didOpen, notebookDocument/didChange and notebookDocument/didClose notifications.
+type NotebookDocumentSyncHandler interface {
+	NotebookDocumentDidOpen(jsonrpc.FunctionLogger, *DidOpenNotebookDocumentParams)
+	NotebookDocumentDidChange(jsonrpc.FunctionLogger, *DidChangeNotebookDocumentParams)
+	NotebookDocumentDidClose(jsonrpc.FunctionLogger, *DidCloseNotebookDocumentParams)
+}
+
+// NotebookDocumentDidSaveHandler is implemented by the handlers that receive the notebookDocument/didSave notification.
+type NotebookDocumentDidSaveHandler interface {
+	NotebookDocumentDidSave(jsonrpc.FunctionLogger, *DidSaveNotebookDocumentParams)
+}
+
+// FillServerCapabilities completes caps with the capabilities of the optional
+// interfaces implemented by the handler: the capabilities of the implemented
+// features that are missing in caps are added, with the options that can be
+// derived from the handler (resolve providers, text document sync, etc.).
+// The capabilities already set in caps are never modified, and the features
+// whose options can not be derived (notebook selector, on-type formatting
+// trigger characters, file operations filters, commands, semantic tokens
+// legend) are never added.
+// The Server calls FillServerCapabilities on the result of Initialize only if
+// enabled with SetFillServerCapabilities, otherwise it can be called by the
+// handler itself.
+func FillServerCapabilities(caps *ServerCapabilities, handler ClientMessagesHandler) {
+	sync := implements[TextDocumentSyncHandler](handler)
+	willSave := implements[WillSaveHandler](handler)
+	willSaveWaitUntil := implements[WillSaveWaitUntilProvider](handler)
+	didSave := implements[DidSaveHandler](handler)
+	if fillOptions(&caps.TextDocumentSync, sync || willSave || willSaveWaitUntil || didSave) {
+		opts := caps.TextDocumentSync
+		opts.OpenClose = sync
+		if sync {
+			opts.Change = TextDocumentSyncKindFull
+		}
+		opts.WillSave = willSave
+		opts.WillSaveWaitUntil = willSaveWaitUntil
+		fillOptions(&opts.Save, didSave)
+	}
+
+	if fillOptions(&caps.CompletionProvider, implements[CompletionProvider](handler)) {
+		caps.CompletionProvider.ResolveProvider = implements[CompletionResolveProvider](handler)
+	}
+	fillOptions(&caps.HoverProvider, implements[HoverProvider](handler))
+	fillOptions(&caps.SignatureHelpProvider, implements[SignatureHelpProvider](handler))
+	fillOptions(&caps.DeclarationProvider, implements[DeclarationProvider](handler))
+	fillOptions(&caps.DefinitionProvider, implements[DefinitionProvider](handler))
+	fillOptions(&caps.TypeDefinitionProvider, implements[TypeDefinitionProvider](handler))
+	fillOptions(&caps.ImplementationProvider, implements[ImplementationProvider](handler))
+	fillOptions(&caps.ReferencesProvider, implements[ReferencesProvider](handler))
+	fillOptions(&caps.DocumentHighlightProvider, implements[DocumentHighlightProvider](handler))
+	fillOptions(&caps.DocumentSymbolProvider, implements[DocumentSymbolProvider](handler))
+	if fillOptions(&caps.CodeActionProvider, implements[CodeActionProvider](handler)) {
+		caps.CodeActionProvider.ResolveProvider = implements[CodeActionResolveProvider](handler)
+	}
+	if fillOptions(&caps.CodeLensProvider, implements[CodeLensProvider](handler)) {
+		caps.CodeLensProvider.ResolveProvider = implements[CodeLensResolveProvider](handler)
+	}
+	if fillOptions(&caps.DocumentLinkProvider, implements[DocumentLinkProvider](handler)) {
+		caps.DocumentLinkProvider.ResolveProvider = implements[DocumentLinkResolveProvider](handler)
+	}
+	fillOptions(&caps.ColorProvider, implements[ColorProvider](handler))
+	fillOptions(&caps.DocumentFormattingProvider, implements[DocumentFormattingProvider](handler))
+	fillOptions(&caps.DocumentRangeFormattingProvider, implements[DocumentRangeFormattingProvider](handler))
+	fillOptions(&caps.RenameProvider, implements[RenameProvider](handler))
+	fillOptions(&caps.FoldingRangeProvider, implements[FoldingRangeProvider](handler))
+	fillOptions(&caps.SelectionRangeProvider, implements[SelectionRangeProvider](handler))
+	fillOptions(&caps.LinkedEditingRangeProvider, implements[LinkedEditingRangeProvider](handler))
+	fillOptions(&caps.CallHierarchyProvider, implements[CallHierarchyProvider](handler))
+	fillOptions(&caps.TypeHierarchyProvider, implements[TypeHierarchyProvider](handler))
+	fillOptions(&caps.MonikerProvider, implements[MonikerProvider](handler))
+	fillOptions(&caps.InlineValueProvider, implements[InlineValueProvider](handler))
+	if fillOptions(&caps.InlayHintProvider, implements[InlayHintProvider](handler)) {
+		caps.InlayHintProvider.ResolveProvider = implements[InlayHintResolveProvider](handler)
+	}
+	if fillOptions(&caps.DiagnosticProvider, implements[DocumentDiagnosticProvider](handler)) {
+		caps.DiagnosticProvider.WorkspaceDiagnostics = implements[WorkspaceDiagnosticProvider](handler)
+	}
+	fillOptions(&caps.WorkspaceSymbolProvider, implements[WorkspaceSymbolProvider](handler))
+}
+
+// implements returns true if the handler implements the interface T.
+func implements[T any](handler ClientMessagesHandler) bool {
+	_, ok := handler.(T)
+	return ok
+}
+
+// fillOptions sets the options to the default value if the feature is
+// implemented and the options are missing. Returns true if the options have
+// been set.
+func fillOptions[T any](opts **T, implemented bool) bool {
+	if !implemented || *opts != nil {
+		return false
+	}
+	*opts = new(T)
+	return true
+}
@@ -0,0 +1,440 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.bug.st/json"
+	"go.bug.st/lsp/jsonrpc"
+)
+
+// providersHandler implements hover, completion with resolve, and the
+// text document synchronization.
+type providersHandler struct {
+	hoverHandler
+	caps ServerCapabilities
+}
+
+func (h *providersHandler) Initialize(ctx context.Context, logger jsonrpc.FunctionLogger, params *InitializeParams) (*InitializeResult, *jsonrpc.ResponseError) {
+	return &InitializeResult{Capabilities: h.caps}, nil
+}
+
+func (h *providersHandler) TextDocumentCompletion(context.Context, jsonrpc.FunctionLogger, *CompletionParams) (*CompletionList, *jsonrpc.ResponseError) {
+	return &CompletionList{}, nil
+}
+
+func (h *providersHandler) CompletionItemResolve(ctx context.Context, logger jsonrpc.FunctionLogger, item *CompletionItem) (*CompletionItem, *jsonrpc.ResponseError) {
+	return item, nil
+}
+
+func (h *providersHandler) TextDocumentDidOpen(jsonrpc.FunctionLogger, *DidOpenTextDocumentParams) {}
+
+func (h *providersHandler) TextDocumentDidChange(jsonrpc.FunctionLogger, *DidChangeTextDocumentParams) {
+}
+
+func (h *providersHandler) TextDocumentDidClose(jsonrpc.FunctionLogger, *DidCloseTextDocumentParams) {
+}
+
+func TestFillServerCapabilities(t *testing.T) {
+	caps := ServerCapabilities{}
+	FillServerCapabilities(&caps, UnimplementedClientMessagesHandler{})
+	data, err := json.Marshal(caps)
+	require.NoError(t, err)
+	require.Equal(t, `{}`, string(data))
+
+	// The missing capabilities of the implemented features are added
+	caps = ServerCapabilities{}
+	FillServerCapabilities(&caps, &providersHandler{})
+	data, err = json.Marshal(caps)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"textDocumentSync": {"openClose": true, "change": 1},
+		"completionProvider": {"resolveProvider": true},
+		"hoverProvider": {}
+	}`, string(data))
+
+	// The capabilities set in advance are never modified
+	caps = ServerCapabilities{
+		CompletionProvider:     &CompletionOptions{TriggerCharacters: []string{"."}},
+		DefinitionProvider:     &DefinitionOptions{},
+		TextDocumentSync:       &TextDocumentSyncOptions{Change: TextDocumentSyncKindIncremental, WillSave: true},
+		ExecuteCommandProvider: &ExecuteCommandOptions{Commands: []string{"cmd"}},
+		RenameProvider:         &RenameOptions{PrepareProvider: true},
+	}
+	FillServerCapabilities(&caps, &providersHandler{})
+	data, err = json.Marshal(caps)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"textDocumentSync": {"change": 2, "willSave": true},
+		"completionProvider": {"triggerCharacters": ["."]},
+		"hoverProvider": {},
+		"definitionProvider": {},
+		"renameProvider": {"prepareProvider": true},
+		"executeCommandProvider": {"commands": ["cmd"]}
+	}`, string(data))
+}
+
+// commandsHandler implements features whose options can't be derived from
+// the handler.
+type commandsHandler struct {
+	UnimplementedClientMessagesHandler
+}
+
+func (h *commandsHandler) WorkspaceExecuteCommand(context.Context, jsonrpc.FunctionLogger, *ExecuteCommandParams) (json.RawMessage, *jsonrpc.ResponseError) {
+	return nil, nil
+}
+
+func (h *commandsHandler) TextDocumentSemanticTokensFull(context.Context, jsonrpc.FunctionLogger, *SemanticTokensParams) (*SemanticTokens, *jsonrpc.ResponseError) {
+	return nil, nil
+}
+
+func TestFillServerCapabilitiesNotDerivable(t *testing.T) {
+	// The commands and the semantic tokens legend must be set by the handler
+	caps := ServerCapabilities{}
+	FillServerCapabilities(&caps, &commandsHandler{})
+	require.Nil(t, caps.ExecuteCommandProvider)
+	require.Nil(t, caps.SemanticTokensProvider)
+}
+
+func TestServerRoutesToProviders(t *testing.T) {
+	input := encodeFrames(
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"processId":null,"rootUri":null,"capabilities":{}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"textDocument":{"uri":"file:///a.txt"},"position":{"line":0,"character":0}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"textDocument/definition","params":{"textDocument":{"uri":"file:///a.txt"},"position":{"line":0,"character":0}}}`,
+		`{"jsonrpc":"2.0","method":"workspace/didChangeConfiguration","params":{"settings":null}}`,
+	)
+	output := &bytes.Buffer{}
+	errs := []string{}
+	serv := NewServer(strings.NewReader(input), output, &providersHandler{})
+	serv.SetErrorHandler(func(e error) { errs = append(errs, e.Error()) })
+	serv.SetFillServerCapabilities(true)
+	serv.Run()
+
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":1,"result":{"capabilities":{"textDocumentSync":{"openClose":true,"change":1},"completionProvider":{"resolveProvider":true},"hoverProvider":{}}}}`)
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":2,"result":{"contents":{"kind":"plaintext","value":"hover"}}}`)
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":3,"error":{"code":-32601,"message":"method not found: textDocument/definition"}}`)
+	require.Equal(t, []string{"EOF"}, errs)
+}
+
+func TestServerFillCapabilitiesOption(t *testing.T) {
+	initialize := encodeFrames(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"processId":null,"rootUri":null,"capabilities":{}}}`)
+	handler := &providersHandler{caps: ServerCapabilities{
+		CompletionProvider: &CompletionOptions{TriggerCharacters: []string{"."}},
+		RenameProvider:     &RenameOptions{PrepareProvider: true},
+	}}
+
+	// The capabilities are not derived unless enabled
+	output := &bytes.Buffer{}
+	NewServer(strings.NewReader(initialize), output, handler).Run()
+	require.Equal(t, encodeFrames(`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{"completionProvider":{"triggerCharacters":["."]},"renameProvider":{"prepareProvider":true}}}}`), output.String())
+
+	// The options set by the handler are kept
+	output = &bytes.Buffer{}
+	serv := NewServer(strings.NewReader(initialize), output, handler)
+	serv.SetFillServerCapabilities(true)
+	serv.Run()
+	require.Equal(t, encodeFrames(`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{"textDocumentSync":{"openClose":true,"change":1},"completionProvider":{"triggerCharacters":["."]},"hoverProvider":{},"renameProvider":{"prepareProvider":true}}}}`), output.String())
+}
+
+// initializedHandler is the base handler of the end-to-end tests, it only
+// accepts the initialize request.
+type initializedHandler struct {
+	UnimplementedClientMessagesHandler
+}
+
+func (initializedHandler) Initialize(ctx context.Context, logger jsonrpc.FunctionLogger, params *InitializeParams) (*InitializeResult, *jsonrpc.ResponseError) {
+	return &InitializeResult{}, nil
+}
+
+// newTestSession connects a Server running the given handler with a Client,
+// the session is initialized and the capabilities derived from the handler
+// are returned. If clientHandler is nil the client doesn't handle any request.
+func newTestSession(t *testing.T, handler ClientMessagesHandler, clientHandler ServerMessagesHandler) (*Server, *Client, ServerCapabilities) {
+	if clientHandler == nil {
+		clientHandler = UnimplementedServerMessagesHandler{}
+	}
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	serv := NewServer(serverIn, serverOut, handler)
+	serv.SetFillServerCapabilities(true)
+	client := NewClient(clientIn, clientOut, clientHandler)
+	go serv.Run()
+	go client.Run()
+	t.Cleanup(func() {
+		client.Close()
+		serv.Close()
+	})
+
+	initRes, resErr, err := client.Initialize(context.Background(), &InitializeParams{})
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	return serv, client, initRes.Capabilities
+}
+
+// inlayHintHandler implements the inlay hint requests.
+type inlayHintHandler struct {
+	initializedHandler
+}
+
+func (h *inlayHintHandler) TextDocumentInlayHint(ctx context.Context, logger jsonrpc.FunctionLogger, params *InlayHintParams) ([]InlayHint, *jsonrpc.ResponseError) {
+	hint := InlayHint{Position: params.Range.Start, Kind: InlayHintKindParameter}
+	hint.Label.Set("param:")
+	return []InlayHint{hint}, nil
+}
+
+func (h *inlayHintHandler) InlayHintResolve(ctx context.Context, logger jsonrpc.FunctionLogger, hint *InlayHint) (*InlayHint, *jsonrpc.ResponseError) {
+	hint.Tooltip = json.RawMessage(`"resolved"`)
+	return hint, nil
+}
+
+// inlayHintRefreshHandler counts the workspace/inlayHint/refresh requests.
+type inlayHintRefreshHandler struct {
+	UnimplementedServerMessagesHandler
+	refreshes int
+}
+
+func (h *inlayHintRefreshHandler) WorkspaceInlayHintRefresh(context.Context, jsonrpc.FunctionLogger) *jsonrpc.ResponseError {
+	h.refreshes++
+	return nil
+}
+
+func TestInlayHints(t *testing.T) {
+	clientHandler := &inlayHintRefreshHandler{}
+	serv, client, caps := newTestSession(t, &inlayHintHandler{}, clientHandler)
+	ctx := context.Background()
+	require.Equal(t, &InlayHintOptions{ResolveProvider: true}, caps.InlayHintProvider)
+
+	hints, resErr, err := client.TextDocumentInlayHint(ctx, &InlayHintParams{
+		TextDocument: TextDocumentIdentifier{URI: NewDocumentURI("/a.go")},
+		Range:        Range{Start: Position{Line: 3, Character: 4}, End: Position{Line: 5}},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	require.Len(t, hints, 1)
+	require.Equal(t, Position{Line: 3, Character: 4}, hints[0].Position)
+	require.Equal(t, "param:", hints[0].Label.Get())
+
+	resolved, resErr, err := client.InlayHintResolve(ctx, &hints[0])
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	require.Equal(t, `"resolved"`, string(resolved.Tooltip))
+
+	resErr, err = serv.WorkspaceInlayHintRefresh(ctx)
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	require.Equal(t, 1, clientHandler.refreshes)
+}
+
+// diagnosticHandler implements the pull diagnostics requests.
+type diagnosticHandler struct {
+	initializedHandler
+}
+
+func (h *diagnosticHandler) TextDocumentDiagnostic(ctx context.Context, logger jsonrpc.FunctionLogger, params *DocumentDiagnosticParams) (*DocumentDiagnosticReport, *jsonrpc.ResponseError) {
+	var res DocumentDiagnosticReport
+	if params.PreviousResultID == "1" {
+		res.Set(RelatedUnchangedDocumentDiagnosticReport{ResultID: "1"})
+	} else {
+		res.Set(RelatedFullDocumentDiagnosticReport{ResultID: "1", Items: []Diagnostic{{Message: "error"}}})
+	}
+	return &res, nil
+}
+
+func (h *diagnosticHandler) WorkspaceDiagnostic(ctx context.Context, logger jsonrpc.FunctionLogger, params *WorkspaceDiagnosticParams) (*WorkspaceDiagnosticReport, *jsonrpc.ResponseError) {
+	res := &WorkspaceDiagnosticReport{Items: []WorkspaceDocumentDiagnosticReport{}}
+	for _, prev := range params.PreviousResultIDs {
+		var item WorkspaceDocumentDiagnosticReport
+		item.Set(WorkspaceUnchangedDocumentDiagnosticReport{URI: prev.URI, ResultID: prev.Value})
+		res.Items = append(res.Items, item)
+	}
+	return res, nil
+}
+
+func TestPullDiagnostics(t *testing.T) {
+	serv, client, caps := newTestSession(t, &diagnosticHandler{}, nil)
+	ctx := context.Background()
+	require.Equal(t, &DiagnosticRegistrationOptions{DiagnosticOptions: DiagnosticOptions{WorkspaceDiagnostics: true}}, caps.DiagnosticProvider)
+
+	doc := TextDocumentIdentifier{URI: NewDocumentURI("/a.c")}
+	report, resErr, err := client.TextDocumentDiagnostic(ctx, &DocumentDiagnosticParams{TextDocument: doc})
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	full := report.Get().(RelatedFullDocumentDiagnosticReport)
+	require.Equal(t, "1", full.ResultID)
+	require.Equal(t, "error", full.Items[0].Message)
+
+	report, resErr, err = client.TextDocumentDiagnostic(ctx, &DocumentDiagnosticParams{TextDocument: doc, PreviousResultID: full.ResultID})
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	require.IsType(t, RelatedUnchangedDocumentDiagnosticReport{}, report.Get())
+
+	wsReport, resErr, err := client.WorkspaceDiagnostic(ctx, &WorkspaceDiagnosticParams{
+		PreviousResultIDs: []PreviousResultID{{URI: doc.URI, Value: "1"}},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	require.Len(t, wsReport.Items, 1)
+	unchanged := wsReport.Items[0].Get().(WorkspaceUnchangedDocumentDiagnosticReport)
+	require.Equal(t, doc.URI, unchanged.URI)
+	require.Nil(t, unchanged.Version)
+
+	resErr, err = serv.WorkspaceDiagnosticRefresh(ctx)
+	require.NoError(t, err)
+	require.Equal(t, jsonrpc.ErrorCodesMethodNotFound, resErr.Code)
+}
+
+// typeHierarchyHandler implements the type hierarchy requests, the items
+// point to their supertype and subtype through the data field.
+type typeHierarchyHandler struct {
+	initializedHandler
+}
+
+func (h *typeHierarchyHandler) item(name string) TypeHierarchyItem {
+	return TypeHierarchyItem{
+		Name: name,
+		Kind: SymbolKindClass,
+		URI:  NewDocumentURI("/a.java"),
+		Data: json.RawMessage(`{"name":"` + name + `"}`),
+	}
+}
+
+func (h *typeHierarchyHandler) TextDocumentPrepareTypeHierarchy(ctx context.Context, logger jsonrpc.FunctionLogger, params *TypeHierarchyPrepareParams) ([]TypeHierarchyItem, *jsonrpc.ResponseError) {
+	return []TypeHierarchyItem{h.item("B")}, nil
+}
+
+func (h *typeHierarchyHandler) TypeHierarchySupertypes(ctx context.Context, logger jsonrpc.FunctionLogger, params *TypeHierarchySupertypesParams) ([]TypeHierarchyItem, *jsonrpc.ResponseError) {
+	if string(params.Item.Data) != `{"name":"B"}` {
+		return nil, &jsonrpc.ResponseError{Code: jsonrpc.ErrorCodesInvalidParams, Message: "unexpected data"}
+	}
+	return []TypeHierarchyItem{h.item("A")}, nil
+}
+
+func (h *typeHierarchyHandler) TypeHierarchySubtypes(ctx context.Context, logger jsonrpc.FunctionLogger, params *TypeHierarchySubtypesParams) ([]TypeHierarchyItem, *jsonrpc.ResponseError) {
+	return nil, nil
+}
+
+func TestTypeHierarchy(t *testing.T) {
+	_, client, caps := newTestSession(t, &typeHierarchyHandler{}, nil)
+	ctx := context.Background()
+	require.NotNil(t, caps.TypeHierarchyProvider)
+
+	items, resErr, err := client.TextDocumentPrepareTypeHierarchy(ctx, &TypeHierarchyPrepareParams{})
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	require.Len(t, items, 1)
+	require.Equal(t, "B", items[0].Name)
+
+	supertypes, resErr, err := client.TypeHierarchySupertypes(ctx, &TypeHierarchySupertypesParams{Item: items[0]})
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	require.Len(t, supertypes, 1)
+	require.Equal(t, "A", supertypes[0].Name)
+	require.Equal(t, `{"name":"A"}`, string(supertypes[0].Data))
+
+	subtypes, resErr, err := client.TypeHierarchySubtypes(ctx, &TypeHierarchySubtypesParams{Item: items[0]})
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	require.Nil(t, subtypes)
+}
+
+// inlineValueHandler implements the textDocument/inlineValue request.
+type inlineValueHandler struct {
+	initializedHandler
+}
+
+func (h *inlineValueHandler) TextDocumentInlineValue(ctx context.Context, logger jsonrpc.FunctionLogger, params *InlineValueParams) ([]InlineValue, *jsonrpc.ResponseError) {
+	line := params.Context.StoppedLocation.End.Line
+	var text, lookup InlineValue
+	text.Set(InlineValueText{Range: Range{Start: Position{Line: line}}, Text: "frame " + strconv.Itoa(params.Context.FrameID)})
+	lookup.Set(InlineValueVariableLookup{Range: Range{Start: Position{Line: line}}, VariableName: "x", CaseSensitiveLookup: true})
+	return []InlineValue{text, lookup}, nil
+}
+
+func TestInlineValues(t *testing.T) {
+	serv, client, caps := newTestSession(t, &inlineValueHandler{}, nil)
+	ctx := context.Background()
+	require.NotNil(t, caps.InlineValueProvider)
+
+	values, resErr, err := client.TextDocumentInlineValue(ctx, &InlineValueParams{
+		TextDocument: TextDocumentIdentifier{URI: NewDocumentURI("/a.go")},
+		Context:      InlineValueContext{FrameID: 7, StoppedLocation: Range{End: Position{Line: 4}}},
+	})
+	require.NoError(t, err)
+	require.Nil(t, resErr)
+	require.Len(t, values, 2)
+	require.Equal(t, "frame 7", values[0].Get().(InlineValueText).Text)
+	require.Equal(t, 4, values[1].Get().(InlineValueVariableLookup).Range.Start.Line)
+
+	resErr, err = serv.WorkspaceInlineValueRefresh(ctx)
+	require.NoError(t, err)
+	require.Equal(t, jsonrpc.ErrorCodesMethodNotFound, resErr.Code)
+}
+
+// notebookHandler records the notebook notifications received.
+type notebookHandler struct {
+	UnimplementedClientMessagesHandler
+	opened []string
+	saved  []string
+	closed []string
+}
+
+func (h *notebookHandler) Initialize(ctx context.Context, logger jsonrpc.FunctionLogger, params *InitializeParams) (*InitializeResult, *jsonrpc.ResponseError) {
+	return &InitializeResult{Capabilities: ServerCapabilities{
+		NotebookDocumentSync: &NotebookDocumentSyncOptions{
+			NotebookSelector: []NotebookSelector{{Notebook: &NotebookDocumentFilter{NotebookType: "jupyter-notebook"}}},
+			Save:             true,
+		},
+	}}, nil
+}
+
+func (h *notebookHandler) NotebookDocumentDidOpen(logger jsonrpc.FunctionLogger, params *DidOpenNotebookDocumentParams) {
+	h.opened = append(h.opened, params.NotebookDocument.String())
+}
+
+func (h *notebookHandler) NotebookDocumentDidChange(logger jsonrpc.FunctionLogger, params *DidChangeNotebookDocumentParams) {
+}
+
+func (h *notebookHandler) NotebookDocumentDidSave(logger jsonrpc.FunctionLogger, params *DidSaveNotebookDocumentParams) {
+	h.saved = append(h.saved, params.NotebookDocument.URI.String())
+}
+
+func (h *notebookHandler) NotebookDocumentDidClose(logger jsonrpc.FunctionLogger, params *DidCloseNotebookDocumentParams) {
+	h.closed = append(h.closed, params.NotebookDocument.URI.String())
+}
+
+func TestNotebookDocumentSync(t *testing.T) {
+	input := encodeFrames(
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"processId":null,"rootUri":null,"capabilities":{}}}`,
+		`{"jsonrpc":"2.0","method":"notebookDocument/didOpen","params":{"notebookDocument":{"uri":"file:///nb.ipynb","notebookType":"jupyter-notebook","version":1,"cells":[{"kind":2,"document":"file:///nb.ipynb/a"}]},"cellTextDocuments":[{"uri":"file:///nb.ipynb/a","languageId":"python","version":1,"text":"x = 1"}]}}`,
+		`{"jsonrpc":"2.0","method":"notebookDocument/didSave","params":{"notebookDocument":{"uri":"file:///nb.ipynb"}}}`,
+		`{"jsonrpc":"2.0","method":"notebookDocument/didClose","params":{"notebookDocument":{"uri":"file:///nb.ipynb"},"cellTextDocuments":[{"uri":"file:///nb.ipynb/a"}]}}`,
+	)
+	output := &bytes.Buffer{}
+	errs := []string{}
+	handler := &notebookHandler{}
+	serv := NewServer(strings.NewReader(input), output, handler)
+	serv.SetErrorHandler(func(e error) { errs = append(errs, e.Error()) })
+	serv.Run()
+
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":1,"result":{"capabilities":{"notebookDocumentSync":{"notebookSelector":[{"notebook":{"notebookType":"jupyter-notebook"}}],"save":true}}}}`)
+	require.Equal(t, []string{"file:///nb.ipynb@1 as 'jupyter-notebook'"}, handler.opened)
+	require.Equal(t, []string{"file:///nb.ipynb"}, handler.saved)
+	require.Equal(t, []string{"file:///nb.ipynb"}, handler.closed)
+	require.Equal(t, []string{"EOF"}, errs)
+
+	var selector NotebookSelector
+	require.NoError(t, json.Unmarshal([]byte(`{"notebook":"jupyter-notebook","cells":[{"language":"python"}]}`), &selector))
+	require.Equal(t, "jupyter-notebook", selector.Notebook.NotebookType)
+	require.Equal(t, "python", selector.Cells[0].Language)
+}
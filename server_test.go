@@ -0,0 +1,138 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.bug.st/json"
+	"go.bug.st/lsp/jsonrpc"
+)
+
+func encodeFrames(msgs ...string) string {
+	res := ""
+	for _, msg := range msgs {
+		res += fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+	return res
+}
+
+// hoverHandler is a ClientMessagesHandler that implements only HoverProvider.
+type hoverHandler struct {
+	UnimplementedClientMessagesHandler
+}
+
+func (h *hoverHandler) TextDocumentHover(ctx context.Context, logger jsonrpc.FunctionLogger, params *HoverParams) (*Hover, *jsonrpc.ResponseError) {
+	return &Hover{Contents: MarkupContent{Kind: MarkupKindPlainText, Value: "hover"}}, nil
+}
+
+func TestServerErrorResponses(t *testing.T) {
+	input := encodeFrames(
+		`{"jsonrpc":"2.0","id":1,"method":"unknown/method","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{"position":"bad"}}`,
+		`{"jsonrpc":"2.0","method":"$/unknownNotification","params":{}}`,
+		`{"jsonrpc":"2.0","method":"unknown/notification","params":{}}`,
+	)
+	output := &bytes.Buffer{}
+	errs := []string{}
+	serv := NewServer(strings.NewReader(input), output, &hoverHandler{})
+	serv.SetErrorHandler(func(e error) { errs = append(errs, e.Error()) })
+	serv.Run()
+
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found: unknown/method"}}`)
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":2,"error":{"code":-32602,`)
+	require.Equal(t, []string{"unimplemented notification: unknown/notification", "EOF"}, errs)
+}
+
+// brokenCommandHandler answers workspace/executeCommand with a result that
+// can not be encoded.
+type brokenCommandHandler struct {
+	UnimplementedClientMessagesHandler
+}
+
+func (h *brokenCommandHandler) WorkspaceExecuteCommand(context.Context, jsonrpc.FunctionLogger, *ExecuteCommandParams) (json.RawMessage, *jsonrpc.ResponseError) {
+	return json.RawMessage(`{"broken"`), nil
+}
+
+func TestServerEncodingErrors(t *testing.T) {
+	input := encodeFrames(
+		`{"jsonrpc":"2.0","id":1,"method":"workspace/executeCommand","params":{"command":"cmd","arguments":[]}}`,
+	)
+	output := &bytes.Buffer{}
+	errs := []string{}
+	serv := NewServer(strings.NewReader(input), output, &brokenCommandHandler{})
+	serv.SetErrorHandler(func(e error) { errs = append(errs, e.Error()) })
+	serv.Run()
+
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"encoding result of workspace/executeCommand:`)
+	require.Len(t, errs, 2)
+	require.Contains(t, errs[0], "encoding result of workspace/executeCommand")
+
+	// Outgoing messages that can not be encoded are not sent
+	output.Reset()
+	client := NewClient(strings.NewReader(""), output, UnimplementedServerMessagesHandler{})
+	_, _, err := client.WorkspaceExecuteCommand(context.Background(), &ExecuteCommandParams{Command: "cmd", Arguments: []interface{}{func() {}}})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "encoding params of workspace/executeCommand")
+	require.Empty(t, output.String())
+}
+
+func TestServerPanicRecovery(t *testing.T) {
+	input := encodeFrames(
+		// The nil handler makes the dispatcher panic
+		`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"unknown/method"}`,
+	)
+	output := &bytes.Buffer{}
+	var panicErr *jsonrpc.HandlerPanicError
+	serv := NewServer(strings.NewReader(input), output, nil)
+	serv.SetErrorHandler(func(e error) {
+		if p, ok := e.(*jsonrpc.HandlerPanicError); ok {
+			panicErr = p
+		}
+	})
+	serv.Run()
+
+	require.NotNil(t, panicErr)
+	require.Equal(t, "shutdown", panicErr.Method)
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":1,"error":{"code":-32603,`)
+	require.Contains(t, output.String(), `{"jsonrpc":"2.0","id":2,"error":{"code":-32601,`)
+}
+
+func TestServerWithFramer(t *testing.T) {
+	input := `{"jsonrpc":"2.0","id":1,"method":"unknown/method"}` + "\n"
+	output := &bytes.Buffer{}
+	serv := NewServer(strings.NewReader(input), output, nil, WithFramer(jsonrpc.NewNewlineFramer))
+	serv.Run()
+	require.Equal(t, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found: unknown/method"}}`+"\n", output.String())
+}
+
+func TestServerOverWebSocket(t *testing.T) {
+	httpServer := httptest.NewServer(jsonrpc.WebSocketHandler(nil, func(r *http.Request, framer jsonrpc.Framer) {
+		NewServerWithFramer(framer, nil).Run()
+	}))
+	defer httpServer.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+	framer, err := jsonrpc.DialWebSocket(context.Background(), wsURL, nil)
+	require.NoError(t, err)
+	conn := jsonrpc.NewConnectionWithFramer(framer, nil, nil, func(e error) {})
+	go conn.Run()
+	defer conn.Close()
+
+	_, resErr, err := conn.SendRequest(context.Background(), "unknown/method", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	require.NotNil(t, resErr)
+	require.Equal(t, jsonrpc.ErrorCodesMethodNotFound, resErr.Code)
+}
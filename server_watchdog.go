@@ -0,0 +1,46 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"fmt"
+	"time"
+
+	"go.bug.st/json"
+)
+
+// startParentProcessWatchdog starts the monitoring of the process with the
+// given pid, if the watchdog is enabled. The watchdog is started only once.
+func (serv *Server) startParentProcessWatchdog(pid int) {
+	interval := serv.parentWatchdogInterval
+	if interval <= 0 || pid <= 0 {
+		return
+	}
+	serv.parentWatchdogOnce.Do(func() {
+		go serv.parentProcessWatchdog(pid, interval)
+	})
+}
+
+func (serv *Server) parentProcessWatchdog(pid int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if serv.conn.IsClosed() {
+			return
+		}
+		if processAlive(pid) {
+			continue
+		}
+		serv.errorHandler(fmt.Errorf("parent process %d terminated", pid))
+		// The exit is dispatched through the connection, serialized with the
+		// incoming messages, then closing the connection cancels the
+		// in-flight requests
+		serv.conn.DispatchLocalNotification("exit", json.RawMessage("null"))
+		serv.conn.Close()
+		return
+	}
+}
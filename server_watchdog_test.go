@@ -0,0 +1,108 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.bug.st/json"
+	"go.bug.st/lsp/jsonrpc"
+)
+
+// watchdogHandler is a ClientMessagesHandler that implements only
+// Initialize and Exit, Exit panics if panics is set.
+type watchdogHandler struct {
+	UnimplementedClientMessagesHandler
+	exited chan struct{}
+	panics bool
+}
+
+func (h *watchdogHandler) Initialize(ctx context.Context, logger jsonrpc.FunctionLogger, params *InitializeParams) (*InitializeResult, *jsonrpc.ResponseError) {
+	return &InitializeResult{}, nil
+}
+
+func (h *watchdogHandler) Exit(logger jsonrpc.FunctionLogger) {
+	close(h.exited)
+	if h.panics {
+		panic("exit failed")
+	}
+}
+
+func TestParentProcessWatchdog(t *testing.T) {
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep command not available")
+	}
+	require.True(t, processAlive(os.Getpid()))
+
+	runWatchdog := func(t *testing.T, handler *watchdogHandler) []error {
+		parent := exec.Command(sleepPath, "60")
+		require.NoError(t, parent.Start())
+		pid := parent.Process.Pid
+		require.True(t, processAlive(pid))
+
+		serverIn, clientOut := io.Pipe()
+		clientIn, serverOut := io.Pipe()
+		errs := make(chan error, 10)
+		serv := NewServer(serverIn, serverOut, handler)
+		serv.SetErrorHandler(func(e error) { errs <- e })
+		serv.SetParentProcessWatchdog(10 * time.Millisecond)
+		go serv.Run()
+
+		client := jsonrpc.NewConnection(clientIn, clientOut, nil, nil, func(e error) {})
+		go client.Run()
+		defer client.Close()
+		params := fmt.Sprintf(`{"processId":%d,"rootUri":null,"capabilities":{}}`, pid)
+		_, resErr, err := client.SendRequest(context.Background(), "initialize", json.RawMessage(params))
+		require.NoError(t, err)
+		require.Nil(t, resErr)
+
+		select {
+		case <-handler.exited:
+			t.Fatal("exit called while the parent process is alive")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		require.NoError(t, parent.Process.Kill())
+		_ = parent.Wait()
+		require.False(t, processAlive(pid))
+
+		select {
+		case <-handler.exited:
+		case <-time.After(5 * time.Second):
+			t.Fatal("exit not called after the parent process terminated")
+		}
+		require.Eventually(t, serv.conn.IsClosed, time.Second, 10*time.Millisecond)
+
+		res := []error{}
+		for len(errs) > 0 {
+			res = append(res, <-errs)
+		}
+		return res
+	}
+
+	t.Run("exit", func(t *testing.T) {
+		errs := runWatchdog(t, &watchdogHandler{exited: make(chan struct{})})
+		require.NotEmpty(t, errs)
+		require.Contains(t, errs[0].Error(), "terminated")
+	})
+
+	t.Run("panicking exit", func(t *testing.T) {
+		// The panic is recovered by the connection and reported
+		errs := runWatchdog(t, &watchdogHandler{exited: make(chan struct{}), panics: true})
+		require.GreaterOrEqual(t, len(errs), 2)
+		require.Contains(t, errs[0].Error(), "terminated")
+		require.IsType(t, &jsonrpc.HandlerPanicError{}, errs[1])
+	})
+}
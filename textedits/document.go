@@ -0,0 +1,145 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package textedits
+
+import (
+	"fmt"
+
+	"go.bug.st/lsp"
+)
+
+// Document is a text document stored in a Rope: the changes are applied
+// without copying the whole text and the index of the lines kept in the Rope
+// converts positions and offsets in logarithmic time even on large documents.
+type Document struct {
+	URI        lsp.DocumentURI
+	LanguageID string
+	Version    int
+
+	// Encoding is the encoding used to interpret the positions in the
+	// document (an empty encoding is UTF-16, the LSP default).
+	Encoding lsp.PositionEncodingKind
+
+	text Rope
+}
+
+// NewDocument creates a Document from the given text document item. The
+// positions are interpreted with the given encoding.
+func NewDocument(doc lsp.TextDocumentItem, encoding lsp.PositionEncodingKind) *Document {
+	return &Document{
+		URI:        doc.URI,
+		LanguageID: doc.LanguageID,
+		Version:    doc.Version,
+		Encoding:   encoding,
+		text:       NewRope(doc.Text),
+	}
+}
+
+// Text returns the text of the document.
+func (d *Document) Text() string {
+	return d.text.String()
+}
+
+// Rope returns a snapshot of the text of the document, the snapshot is not
+// affected by the changes applied later to the document.
+func (d *Document) Rope() Rope {
+	return d.text
+}
+
+// TextDocumentItem returns the lsp.TextDocumentItem of the document.
+func (d *Document) TextDocumentItem() lsp.TextDocumentItem {
+	return lsp.TextDocumentItem{
+		URI:        d.URI,
+		LanguageID: d.LanguageID,
+		Version:    d.Version,
+		Text:       d.Text(),
+	}
+}
+
+// LineCount returns the number of lines of the document.
+func (d *Document) LineCount() int {
+	return d.text.LineCount()
+}
+
+// GetLineOffset returns the offset of the beginning of the line.
+// Returns OutOfRangeError if the line is out of range.
+func (d *Document) GetLineOffset(line int) (int, error) {
+	return d.text.GetLineOffset(line)
+}
+
+// GetOffset computes the byte offset in the document expressed by the
+// lsp.Position. If the position points inside a character, the offset of
+// the character is returned.
+// Returns OutOfRangeError if the position is out of range.
+func (d *Document) GetOffset(pos lsp.Position) (int, error) {
+	return d.text.GetOffset(pos, d.Encoding)
+}
+
+// GetPosition computes the lsp.Position of the byte offset in the document.
+// An offset between "\r" and "\n" is moved to the end of its line.
+// Returns OutOfRangeError if the offset is out of range.
+func (d *Document) GetPosition(offset int) (lsp.Position, error) {
+	return d.text.GetPosition(offset, d.Encoding)
+}
+
+// ConvertPosition converts the position in the document to the given
+// encoding. Positions beyond the end of a line are moved to the end of the
+// line.
+func (d *Document) ConvertPosition(pos lsp.Position, encoding lsp.PositionEncodingKind) (lsp.Position, error) {
+	offset, err := d.GetOffset(pos)
+	if err != nil {
+		return lsp.Position{}, err
+	}
+	return d.text.GetPosition(offset, encoding)
+}
+
+// ConvertRange converts the range in the document to the given encoding.
+func (d *Document) ConvertRange(textRange lsp.Range, encoding lsp.PositionEncodingKind) (lsp.Range, error) {
+	start, err := d.ConvertPosition(textRange.Start, encoding)
+	if err != nil {
+		return lsp.Range{}, err
+	}
+	end, err := d.ConvertPosition(textRange.End, encoding)
+	if err != nil {
+		return lsp.Range{}, err
+	}
+	return lsp.Range{Start: start, End: end}, nil
+}
+
+// ExtractRange extract a piece of text from the document given the range.
+func (d *Document) ExtractRange(textRange lsp.Range) (string, error) {
+	return d.text.ExtractRange(textRange, d.Encoding)
+}
+
+// ApplyTextChange replaces the text specified by replaceRange with
+// insertText.
+func (d *Document) ApplyTextChange(replaceRange lsp.Range, insertText string) error {
+	text, err := d.text.ApplyTextChange(replaceRange, insertText, d.Encoding)
+	if err != nil {
+		return err
+	}
+	d.text = text
+	return nil
+}
+
+// ApplyLSPTextDocumentContentChangeEvent applies the LSP change to the
+// document and sets the version of the document to the one of the change.
+// The change is applied as a whole: if it fails the document is left
+// untouched.
+func (d *Document) ApplyLSPTextDocumentContentChangeEvent(changes *lsp.DidChangeTextDocumentParams) error {
+	if changes.TextDocument.URI != d.URI {
+		return fmt.Errorf("expected changes for %s but got changes for: %s", d.URI, changes.TextDocument.URI)
+	}
+
+	text, err := d.text.ApplyTextDocumentContentChanges(changes.ContentChanges, d.Encoding)
+	if err != nil {
+		return err
+	}
+	d.text = text
+	d.Version = changes.TextDocument.Version
+	return nil
+}
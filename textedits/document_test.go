@@ -0,0 +1,121 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package textedits
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"go.bug.st/lsp"
+)
+
+func TestDocumentIncrementalChanges(t *testing.T) {
+	pieces := []string{"a", "bc", "è", "😀", "\n", "\r", "\r\n", ""}
+	randomText := func(r *rand.Rand) string {
+		var s strings.Builder
+		for n := r.Intn(6); n > 0; n-- {
+			s.WriteString(pieces[r.Intn(len(pieces))])
+		}
+		return s.String()
+	}
+
+	r := rand.New(rand.NewSource(1))
+	doc := NewDocument(lsp.TextDocumentItem{Text: "foo\r\nbar\rbaz\n"}, lsp.PositionEncodingKindUTF16)
+	for i := 0; i < 2000; i++ {
+		start := lsp.Position{Line: r.Intn(doc.LineCount()), Character: r.Intn(4)}
+		end := lsp.Position{Line: start.Line + r.Intn(2), Character: r.Intn(4)}
+		if end.Line >= doc.LineCount() || end.Line == start.Line && end.Character < start.Character {
+			end = start
+		}
+		insert := randomText(r)
+
+		exp, err := ApplyTextChange(doc.Text(), lsp.Range{Start: start, End: end}, insert, lsp.PositionEncodingKindUTF16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := doc.ApplyTextChange(lsp.Range{Start: start, End: end}, insert); err != nil {
+			t.Fatal(err)
+		}
+		if doc.Text() != exp {
+			t.Fatalf("change %d: expected %q, got %q", i, exp, doc.Text())
+		}
+		starts := lineStarts(exp)
+		if doc.LineCount() != len(starts) {
+			t.Fatalf("change %d: expected %d lines in %q, got %d", i, len(starts), exp, doc.LineCount())
+		}
+		for line, start := range starts {
+			if act, err := doc.GetLineOffset(line); act != start || err != nil {
+				t.Fatalf("change %d: line index of %q out of sync: expected line %d at %d, got %d, %v", i, exp, line, start, act, err)
+			}
+		}
+	}
+}
+
+// lineStarts returns the offsets of the beginning of the lines of the text.
+func lineStarts(text string) []int {
+	starts := []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' || text[i] == '\r' && (i+1 == len(text) || text[i+1] != '\n') {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+func TestDocument(t *testing.T) {
+	uri := lsp.NewDocumentURI("/test.txt")
+	doc := NewDocument(lsp.TextDocumentItem{URI: uri, Version: 1, Text: "a\r\nèa😀b\nc"}, lsp.PositionEncodingKindUTF16)
+	if doc.LineCount() != 3 {
+		t.Errorf("expected 3 lines, got %d", doc.LineCount())
+	}
+	if offset, err := doc.GetOffset(lsp.Position{Line: 1, Character: 4}); offset != 10 || err != nil {
+		t.Errorf("getOffset: got %d, %v", offset, err)
+	}
+	if pos, err := doc.GetPosition(10); pos != (lsp.Position{Line: 1, Character: 4}) || err != nil {
+		t.Errorf("getPosition: got %v, %v", pos, err)
+	}
+	if pos, err := doc.ConvertPosition(lsp.Position{Line: 1, Character: 4}, lsp.PositionEncodingKindUTF8); pos != (lsp.Position{Line: 1, Character: 7}) || err != nil {
+		t.Errorf("convertPosition: got %v, %v", pos, err)
+	}
+	if _, err := doc.GetOffset(lsp.Position{Line: 3}); err != (OutOfRangeError{"Line", 2, 3}) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+
+	err := doc.ApplyLSPTextDocumentContentChangeEvent(&lsp.DidChangeTextDocumentParams{
+		TextDocument: lsp.VersionedTextDocumentIdentifier{TextDocumentIdentifier: lsp.TextDocumentIdentifier{URI: uri}, Version: 5},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{
+			{Range: &lsp.Range{Start: lsp.Position{Line: 0, Character: 1}, End: lsp.Position{Line: 1, Character: 0}}, Text: "\n"},
+			{Range: &lsp.Range{Start: lsp.Position{Line: 2, Character: 1}, End: lsp.Position{Line: 2, Character: 1}}, Text: "\rd"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "a\nèa😀b\nc\rd"; doc.Text() != exp || doc.LineCount() != 4 || doc.Version != 5 {
+		t.Errorf("expected %q with 4 lines at version 5, got %q with %d lines at version %d", exp, doc.Text(), doc.LineCount(), doc.Version)
+	}
+
+	// Failing changes leave the document untouched
+	err = doc.ApplyLSPTextDocumentContentChangeEvent(&lsp.DidChangeTextDocumentParams{
+		TextDocument: lsp.VersionedTextDocumentIdentifier{TextDocumentIdentifier: lsp.TextDocumentIdentifier{URI: uri}, Version: 6},
+		ContentChanges: []lsp.TextDocumentContentChangeEvent{
+			{Text: "x\ny"},
+			{Range: &lsp.Range{Start: lsp.Position{Line: 5}, End: lsp.Position{Line: 5}}, Text: "z"},
+		},
+	})
+	if err != (OutOfRangeError{"Line", 1, 5}) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+	if exp := "a\nèa😀b\nc\rd"; doc.Text() != exp || doc.LineCount() != 4 || doc.Version != 5 {
+		t.Errorf("expected %q with 4 lines at version 5, got %q with %d lines at version %d", exp, doc.Text(), doc.LineCount(), doc.Version)
+	}
+
+	if err := doc.ApplyTextChange(lsp.Range{Start: lsp.Position{Line: 1, Character: 1}, End: lsp.Position{Line: 0, Character: 0}}, ""); err == nil {
+		t.Errorf("expected error for reversed range")
+	}
+}
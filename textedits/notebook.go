@@ -0,0 +1,127 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package textedits
+
+import (
+	"fmt"
+
+	"go.bug.st/lsp"
+)
+
+// Notebook is a notebook document together with the text documents of its
+// cells, it can be kept in sync with the client by applying the
+// notebookDocument/didChange notifications.
+type Notebook struct {
+	lsp.NotebookDocument
+
+	// CellTextDocuments are the text documents of the cells by URI.
+	CellTextDocuments map[lsp.DocumentURI]lsp.TextDocumentItem
+}
+
+// NewNotebook creates a Notebook from the notebookDocument/didOpen
+// notification params.
+func NewNotebook(params *lsp.DidOpenNotebookDocumentParams) *Notebook {
+	notebook := &Notebook{
+		NotebookDocument:  params.NotebookDocument,
+		CellTextDocuments: map[lsp.DocumentURI]lsp.TextDocumentItem{},
+	}
+	notebook.Cells = append([]lsp.NotebookCell{}, params.NotebookDocument.Cells...)
+	for _, doc := range params.CellTextDocuments {
+		notebook.CellTextDocuments[doc.URI] = doc
+	}
+	return notebook
+}
+
+// ApplyLSPNotebookDocumentChangeEvent applies the LSP change to the notebook,
+// the positions of the cell text changes are interpreted with the given
+// encoding. The change is applied as a whole: if it fails the notebook is
+// left untouched.
+func (n *Notebook) ApplyLSPNotebookDocumentChangeEvent(changes *lsp.DidChangeNotebookDocumentParams, encoding lsp.PositionEncodingKind) error {
+	if changes.NotebookDocument.URI != n.URI {
+		return fmt.Errorf("expected changes for %s but got changes for: %s", n.URI, changes.NotebookDocument.URI)
+	}
+
+	cells := append([]lsp.NotebookCell{}, n.Cells...)
+	docs := make(map[lsp.DocumentURI]lsp.TextDocumentItem, len(n.CellTextDocuments))
+	for uri, doc := range n.CellTextDocuments {
+		docs[uri] = doc
+	}
+
+	if cellChanges := changes.Change.Cells; cellChanges != nil {
+		if structure := cellChanges.Structure; structure != nil {
+			array := structure.Array
+			if array.Start < 0 || array.Start > len(cells) {
+				return OutOfRangeError{"Cell", len(cells), array.Start}
+			}
+			if array.DeleteCount < 0 || array.Start+array.DeleteCount > len(cells) {
+				return OutOfRangeError{"Cell", len(cells), array.Start + array.DeleteCount}
+			}
+			tail := cells[array.Start+array.DeleteCount:]
+			cells = append(append(cells[:array.Start:array.Start], array.Cells...), tail...)
+
+			for _, doc := range structure.DidClose {
+				delete(docs, doc.URI)
+			}
+			for _, doc := range structure.DidOpen {
+				docs[doc.URI] = doc
+			}
+		}
+
+		for _, data := range cellChanges.Data {
+			idx := findCell(cells, data.Document)
+			if idx == -1 {
+				return fmt.Errorf("unknown cell: %s", data.Document)
+			}
+			cells[idx] = data
+		}
+
+		for _, content := range cellChanges.TextContent {
+			doc, ok := docs[content.Document.URI]
+			if !ok {
+				return fmt.Errorf("unknown cell text document: %s", content.Document.URI)
+			}
+			doc, err := ApplyLSPTextDocumentContentChangeEvent(doc, &lsp.DidChangeTextDocumentParams{
+				TextDocument:   content.Document,
+				ContentChanges: content.Changes,
+			}, encoding)
+			if err != nil {
+				return err
+			}
+			docs[doc.URI] = doc
+		}
+	}
+
+	if changes.Change.Metadata != nil {
+		n.Metadata = changes.Change.Metadata
+	}
+	n.Cells = cells
+	n.CellTextDocuments = docs
+	n.Version = changes.NotebookDocument.Version
+	return nil
+}
+
+// CellTextDocument returns the text document of the cell at the given index.
+func (n *Notebook) CellTextDocument(index int) (lsp.TextDocumentItem, error) {
+	if index < 0 || index >= len(n.Cells) {
+		return lsp.TextDocumentItem{}, OutOfRangeError{"Cell", len(n.Cells), index}
+	}
+	uri := n.Cells[index].Document
+	doc, ok := n.CellTextDocuments[uri]
+	if !ok {
+		return lsp.TextDocumentItem{}, fmt.Errorf("missing cell text document: %s", uri)
+	}
+	return doc, nil
+}
+
+func findCell(cells []lsp.NotebookCell, uri lsp.DocumentURI) int {
+	for i, cell := range cells {
+		if cell.Document == uri {
+			return i
+		}
+	}
+	return -1
+}
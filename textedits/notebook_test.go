@@ -0,0 +1,132 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package textedits
+
+import (
+	"testing"
+
+	"go.bug.st/lsp"
+)
+
+func TestNotebook(t *testing.T) {
+	notebookURI := lsp.NewDocumentURI("/nb.ipynb")
+	cell := func(name string) lsp.NotebookCell {
+		return lsp.NotebookCell{Kind: lsp.NotebookCellKindCode, Document: lsp.NewDocumentURI("/nb.ipynb/" + name)}
+	}
+	cellDoc := func(name, text string) lsp.TextDocumentItem {
+		return lsp.TextDocumentItem{URI: lsp.NewDocumentURI("/nb.ipynb/" + name), LanguageID: "python", Version: 1, Text: text}
+	}
+	checkCells := func(n *Notebook, texts ...string) {
+		t.Helper()
+		if len(n.Cells) != len(texts) {
+			t.Fatalf("expected %d cells, got %d", len(texts), len(n.Cells))
+		}
+		for i, text := range texts {
+			doc, err := n.CellTextDocument(i)
+			if err != nil {
+				t.Fatalf("cell %d: %s", i, err)
+			}
+			if doc.Text != text {
+				t.Errorf("cell %d: expected \"%s\", got \"%s\"", i, text, doc.Text)
+			}
+		}
+	}
+
+	n := NewNotebook(&lsp.DidOpenNotebookDocumentParams{
+		NotebookDocument:  lsp.NotebookDocument{URI: notebookURI, NotebookType: "jupyter-notebook", Version: 1, Cells: []lsp.NotebookCell{cell("a"), cell("b")}},
+		CellTextDocuments: []lsp.TextDocumentItem{cellDoc("a", "x = 1\n"), cellDoc("b", "print(x)\n")},
+	})
+	checkCells(n, "x = 1\n", "print(x)\n")
+
+	// Replace cell "a" with "c" and edit cell "b"
+	err := n.ApplyLSPNotebookDocumentChangeEvent(&lsp.DidChangeNotebookDocumentParams{
+		NotebookDocument: lsp.VersionedNotebookDocumentIdentifier{URI: notebookURI, Version: 2},
+		Change: lsp.NotebookDocumentChangeEvent{
+			Cells: &lsp.NotebookDocumentCellChanges{
+				Structure: &lsp.NotebookDocumentCellChangeStructure{
+					Array:    lsp.NotebookCellArrayChange{Start: 0, DeleteCount: 1, Cells: []lsp.NotebookCell{cell("c")}},
+					DidOpen:  []lsp.TextDocumentItem{cellDoc("c", "y = 2\n")},
+					DidClose: []lsp.TextDocumentIdentifier{{URI: cell("a").Document}},
+				},
+				TextContent: []lsp.NotebookDocumentCellContentChanges{{
+					Document: lsp.VersionedTextDocumentIdentifier{TextDocumentIdentifier: lsp.TextDocumentIdentifier{URI: cell("b").Document}, Version: 5},
+					Changes:  []lsp.TextDocumentContentChangeEvent{{Range: &lsp.Range{Start: lsp.Position{Line: 0, Character: 6}, End: lsp.Position{Line: 0, Character: 7}}, Text: "y"}},
+				}},
+			},
+		},
+	}, lsp.PositionEncodingKindUTF16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCells(n, "y = 2\n", "print(y)\n")
+	if n.Version != 2 {
+		t.Errorf("expected notebook version 2, got %d", n.Version)
+	}
+	if doc := n.CellTextDocuments[cell("b").Document]; doc.Version != 5 {
+		t.Errorf("expected cell version 5, got %d", doc.Version)
+	}
+	if _, ok := n.CellTextDocuments[cell("a").Document]; ok {
+		t.Errorf("closed cell text document still present")
+	}
+
+	// Append a cell and update the data of the first one
+	err = n.ApplyLSPNotebookDocumentChangeEvent(&lsp.DidChangeNotebookDocumentParams{
+		NotebookDocument: lsp.VersionedNotebookDocumentIdentifier{URI: notebookURI, Version: 3},
+		Change: lsp.NotebookDocumentChangeEvent{
+			Cells: &lsp.NotebookDocumentCellChanges{
+				Structure: &lsp.NotebookDocumentCellChangeStructure{
+					Array:   lsp.NotebookCellArrayChange{Start: 2, Cells: []lsp.NotebookCell{cell("d")}},
+					DidOpen: []lsp.TextDocumentItem{cellDoc("d", "")},
+				},
+				Data: []lsp.NotebookCell{{Kind: lsp.NotebookCellKindMarkup, Document: cell("c").Document}},
+			},
+		},
+	}, lsp.PositionEncodingKindUTF16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCells(n, "y = 2\n", "print(y)\n", "")
+	if n.Cells[0].Kind != lsp.NotebookCellKindMarkup {
+		t.Errorf("expected markup cell, got %d", n.Cells[0].Kind)
+	}
+
+	// Failing changes leave the notebook untouched
+	err = n.ApplyLSPNotebookDocumentChangeEvent(&lsp.DidChangeNotebookDocumentParams{
+		NotebookDocument: lsp.VersionedNotebookDocumentIdentifier{URI: notebookURI, Version: 4},
+		Change: lsp.NotebookDocumentChangeEvent{
+			Cells: &lsp.NotebookDocumentCellChanges{
+				Structure: &lsp.NotebookDocumentCellChangeStructure{
+					Array: lsp.NotebookCellArrayChange{Start: 0, DeleteCount: 1},
+				},
+				TextContent: []lsp.NotebookDocumentCellContentChanges{{
+					Document: lsp.VersionedTextDocumentIdentifier{TextDocumentIdentifier: lsp.TextDocumentIdentifier{URI: cell("x").Document}, Version: 1},
+				}},
+			},
+		},
+	}, lsp.PositionEncodingKindUTF16)
+	if err == nil {
+		t.Fatal("expected error for unknown cell")
+	}
+	checkCells(n, "y = 2\n", "print(y)\n", "")
+
+	err = n.ApplyLSPNotebookDocumentChangeEvent(&lsp.DidChangeNotebookDocumentParams{
+		NotebookDocument: lsp.VersionedNotebookDocumentIdentifier{URI: notebookURI, Version: 4},
+		Change: lsp.NotebookDocumentChangeEvent{
+			Cells: &lsp.NotebookDocumentCellChanges{
+				Structure: &lsp.NotebookDocumentCellChangeStructure{
+					Array: lsp.NotebookCellArrayChange{Start: 2, DeleteCount: 2},
+				},
+			},
+		},
+	}, lsp.PositionEncodingKindUTF16)
+	if err != (OutOfRangeError{"Cell", 3, 4}) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+	if n.Version != 3 {
+		t.Errorf("expected notebook version 3, got %d", n.Version)
+	}
+}
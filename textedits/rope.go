@@ -0,0 +1,420 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package textedits
+
+import (
+	"fmt"
+	"strings"
+
+	"go.bug.st/lsp"
+)
+
+// ropeMaxLeaf is the maximum size of the text stored in a leaf of a Rope.
+const ropeMaxLeaf = 512
+
+// Rope is an immutable text buffer, stored as a balanced tree of text chunks.
+// Changes return a new Rope that shares most of its structure with the
+// original one, so that edits are cheap even on large documents and every
+// Rope is a stable snapshot of the text that can be safely read from
+// concurrent goroutines. The zero value is an empty text.
+type Rope struct {
+	root *ropeNode
+}
+
+type ropeNode struct {
+	left, right *ropeNode
+	text        string // only for leaves
+
+	length int
+	height int
+
+	// starts is the number of line starts in the node, as if the text of
+	// the node were a standalone text.
+	starts int
+
+	// first and last are the first and last byte of the text of the node.
+	first, last byte
+}
+
+// NewRope creates a Rope with the given text.
+func NewRope(text string) Rope {
+	return Rope{root: buildRope(text)}
+}
+
+// Len returns the length of the text in bytes.
+func (r Rope) Len() int {
+	if r.root == nil {
+		return 0
+	}
+	return r.root.length
+}
+
+// String returns the text of the Rope.
+func (r Rope) String() string {
+	var s strings.Builder
+	s.Grow(r.Len())
+	r.root.writeTo(&s, 0, r.Len())
+	return s.String()
+}
+
+// Slice returns the text between the start and end offsets.
+// Returns OutOfRangeError if the offsets are out of range.
+func (r Rope) Slice(start, end int) (string, error) {
+	if err := r.checkOffsets(start, end); err != nil {
+		return "", err
+	}
+	var s strings.Builder
+	s.Grow(end - start)
+	r.root.writeTo(&s, start, end)
+	return s.String(), nil
+}
+
+// Replace returns a new Rope with the text between the start and end offsets
+// replaced by insertText.
+// Returns OutOfRangeError if the offsets are out of range.
+func (r Rope) Replace(start, end int, insertText string) (Rope, error) {
+	if err := r.checkOffsets(start, end); err != nil {
+		return Rope{}, err
+	}
+	left, rest := splitRope(r.root, start)
+	_, right := splitRope(rest, end-start)
+	return Rope{root: joinRope(joinRope(left, buildRope(insertText)), right)}, nil
+}
+
+// LineCount returns the number of lines of the text.
+func (r Rope) LineCount() int {
+	if r.root == nil {
+		return 1
+	}
+	return r.root.starts + 1
+}
+
+// GetLineOffset returns the offset of the beginning of the line.
+// Returns OutOfRangeError if the line is out of range.
+func (r Rope) GetLineOffset(line int) (int, error) {
+	if line < 0 || line >= r.LineCount() {
+		return -1, OutOfRangeError{"Line", r.LineCount() - 1, line}
+	}
+	if line == 0 {
+		return 0, nil
+	}
+	return r.root.lineStart(line)
+}
+
+// GetOffset computes the byte offset in the text expressed by the
+// lsp.Position, where the Character of the position counts the code units
+// of the given encoding. If the position points inside a character, the
+// offset of the character is returned.
+// Returns OutOfRangeError if the position is out of range.
+func (r Rope) GetOffset(pos lsp.Position, encoding lsp.PositionEncodingKind) (int, error) {
+	start, line, err := r.line(pos.Line)
+	if err != nil {
+		return -1, err
+	}
+	offset, err := characterOffset(line, pos.Character, encoding)
+	if err != nil {
+		return -1, err
+	}
+	return start + offset, nil
+}
+
+// GetPosition computes the lsp.Position of the byte offset in the text, the
+// Character of the position counts the code units of the given encoding.
+// An offset between "\r" and "\n" is moved to the end of its line.
+// Returns OutOfRangeError if the offset is out of range.
+func (r Rope) GetPosition(offset int, encoding lsp.PositionEncodingKind) (lsp.Position, error) {
+	if offset < 0 || offset > r.Len() {
+		return lsp.Position{}, OutOfRangeError{"Offset", r.Len(), offset}
+	}
+	lineNumber := 0
+	if offset > 0 {
+		lineNumber = r.root.startsUpTo(offset)
+	}
+	start, line, _ := r.line(lineNumber)
+	if offset-start > len(line) {
+		offset = start + len(line)
+	}
+	return lsp.Position{Line: lineNumber, Character: encodedLen(line[:offset-start], encoding)}, nil
+}
+
+// ExtractRange extract a piece of text given the range. The range is
+// interpreted with the given encoding.
+func (r Rope) ExtractRange(textRange lsp.Range, encoding lsp.PositionEncodingKind) (string, error) {
+	start, end, err := r.rangeOffsets(textRange, encoding)
+	if err != nil {
+		return "", err
+	}
+	return r.Slice(start, end)
+}
+
+// ApplyTextChange returns a new Rope with the text specified by replaceRange
+// replaced by insertText. The range is interpreted with the given encoding.
+func (r Rope) ApplyTextChange(replaceRange lsp.Range, insertText string, encoding lsp.PositionEncodingKind) (Rope, error) {
+	start, end, err := r.rangeOffsets(replaceRange, encoding)
+	if err != nil {
+		return Rope{}, err
+	}
+	return r.Replace(start, end, insertText)
+}
+
+// ApplyTextDocumentContentChanges returns a new Rope with the LSP changes
+// applied. The positions of the changes are interpreted with the given
+// encoding.
+func (r Rope) ApplyTextDocumentContentChanges(changes []lsp.TextDocumentContentChangeEvent, encoding lsp.PositionEncodingKind) (Rope, error) {
+	for _, change := range changes {
+		if change.Range == nil {
+			r = NewRope(change.Text)
+		} else if res, err := r.ApplyTextChange(*change.Range, change.Text, encoding); err == nil {
+			r = res
+		} else {
+			return Rope{}, err
+		}
+	}
+	return r, nil
+}
+
+// line returns the offset and the text of the line, the line terminator is
+// not included.
+func (r Rope) line(line int) (int, string, error) {
+	start, err := r.GetLineOffset(line)
+	if err != nil {
+		return -1, "", err
+	}
+	if line+1 == r.LineCount() {
+		text, _ := r.Slice(start, r.Len())
+		return start, text, nil
+	}
+	end, err := r.root.lineStart(line + 1)
+	if err != nil {
+		return -1, "", err
+	}
+	text, _ := r.Slice(start, end)
+	if strings.HasSuffix(text, "\r\n") {
+		return start, text[:len(text)-2], nil
+	}
+	return start, text[:len(text)-1], nil
+}
+
+func (r Rope) rangeOffsets(textRange lsp.Range, encoding lsp.PositionEncodingKind) (int, int, error) {
+	start, err := r.GetOffset(textRange.Start, encoding)
+	if err != nil {
+		return -1, -1, err
+	}
+	end, err := r.GetOffset(textRange.End, encoding)
+	if err != nil {
+		return -1, -1, err
+	}
+	if end < start {
+		return -1, -1, fmt.Errorf("invalid range %s: end before start", textRange)
+	}
+	return start, end, nil
+}
+
+func (r Rope) checkOffsets(start, end int) error {
+	if start < 0 || start > r.Len() {
+		return OutOfRangeError{"Offset", r.Len(), start}
+	}
+	if end < start || end > r.Len() {
+		return OutOfRangeError{"Offset", r.Len(), end}
+	}
+	return nil
+}
+
+// buildRope builds a balanced tree with the given text.
+func buildRope(text string) *ropeNode {
+	if len(text) <= ropeMaxLeaf {
+		return newRopeLeaf(text)
+	}
+	mid := len(text) / 2
+	return newRopeNode(buildRope(text[:mid]), buildRope(text[mid:]))
+}
+
+func newRopeLeaf(text string) *ropeNode {
+	if text == "" {
+		return nil
+	}
+	return &ropeNode{
+		text:   text,
+		length: len(text),
+		height: 1,
+		starts: strings.Count(text, "\n") + strings.Count(text, "\r") - strings.Count(text, "\r\n"),
+		first:  text[0],
+		last:   text[len(text)-1],
+	}
+}
+
+// newRopeNode joins two nodes, small leaves are merged together.
+func newRopeNode(left, right *ropeNode) *ropeNode {
+	if left == nil {
+		return right
+	}
+	if right == nil {
+		return left
+	}
+	if left.isLeaf() && right.isLeaf() && left.length+right.length <= ropeMaxLeaf {
+		return newRopeLeaf(left.text + right.text)
+	}
+	return &ropeNode{
+		left:   left,
+		right:  right,
+		length: left.length + right.length,
+		height: max(left.height, right.height) + 1,
+		starts: left.starts + right.starts - crlf(left.last, right.first),
+		first:  left.first,
+		last:   right.last,
+	}
+}
+
+// joinRope concatenates two balanced trees in a balanced tree.
+func joinRope(left, right *ropeNode) *ropeNode {
+	switch {
+	case left == nil:
+		return right
+	case right == nil:
+		return left
+	case left.height > right.height+1:
+		return balanceRope(left.left, joinRope(left.right, right))
+	case right.height > left.height+1:
+		return balanceRope(joinRope(left, right.left), right.right)
+	default:
+		return newRopeNode(left, right)
+	}
+}
+
+// balanceRope joins two balanced trees whose heights differ at most by two.
+func balanceRope(left, right *ropeNode) *ropeNode {
+	switch {
+	case left.height > right.height+1:
+		if left.left.height >= left.right.height {
+			return newRopeNode(left.left, newRopeNode(left.right, right))
+		}
+		return newRopeNode(newRopeNode(left.left, left.right.left), newRopeNode(left.right.right, right))
+	case right.height > left.height+1:
+		if right.right.height >= right.left.height {
+			return newRopeNode(newRopeNode(left, right.left), right.right)
+		}
+		return newRopeNode(newRopeNode(left, right.left.left), newRopeNode(right.left.right, right.right))
+	default:
+		return newRopeNode(left, right)
+	}
+}
+
+// splitRope splits the tree at the given offset.
+func splitRope(n *ropeNode, offset int) (*ropeNode, *ropeNode) {
+	switch {
+	case n == nil:
+		return nil, nil
+	case n.isLeaf():
+		return newRopeLeaf(n.text[:offset]), newRopeLeaf(n.text[offset:])
+	case offset == n.left.length:
+		return n.left, n.right
+	case offset < n.left.length:
+		left, right := splitRope(n.left, offset)
+		return left, joinRope(right, n.right)
+	default:
+		left, right := splitRope(n.right, offset-n.left.length)
+		return joinRope(n.left, left), right
+	}
+}
+
+func (n *ropeNode) isLeaf() bool {
+	return n.left == nil
+}
+
+// writeTo writes the text of the node between the start and end offsets.
+func (n *ropeNode) writeTo(s *strings.Builder, start, end int) {
+	if n == nil || start >= end {
+		return
+	}
+	if n.isLeaf() {
+		s.WriteString(n.text[start:end])
+		return
+	}
+	if start < n.left.length {
+		n.left.writeTo(s, start, min(end, n.left.length))
+	}
+	if end > n.left.length {
+		n.right.writeTo(s, max(start-n.left.length, 0), end-n.left.length)
+	}
+}
+
+// lineStart returns the offset of the n-th line start (counting from 1).
+// Returns OutOfRangeError if the node has fewer line starts.
+func (n *ropeNode) lineStart(line int) (int, error) {
+	notFound := OutOfRangeError{"Line", n.starts, line}
+
+	// A "\r" at the end of a node is a line start only if the following
+	// text doesn't begin with "\n", so the byte following the node is
+	// tracked while descending the tree.
+	offset := 0
+	var next byte
+	for !n.isLeaf() {
+		leftStarts := n.left.starts - crlf(n.left.last, n.right.first)
+		if line <= leftStarts {
+			next = n.right.first
+			n = n.left
+		} else {
+			line -= leftStarts
+			offset += n.left.length
+			n = n.right
+		}
+	}
+	for i := 0; i < len(n.text); i++ {
+		if isLineStart(n.text, i+1, next) {
+			line--
+			if line == 0 {
+				return offset + i + 1, nil
+			}
+		}
+	}
+	return -1, notFound
+}
+
+// startsUpTo returns the number of line starts up to the given offset.
+func (n *ropeNode) startsUpTo(offset int) int {
+	count := 0
+	var next byte
+	for !n.isLeaf() {
+		if offset <= n.left.length {
+			next = n.right.first
+			n = n.left
+		} else {
+			count += n.left.starts - crlf(n.left.last, n.right.first)
+			offset -= n.left.length
+			n = n.right
+		}
+	}
+	for i := 1; i <= offset; i++ {
+		if isLineStart(n.text, i, next) {
+			count++
+		}
+	}
+	return count
+}
+
+// isLineStart returns true if a line starts at the given offset of the text,
+// next is the byte following the text.
+func isLineStart(text string, offset int, next byte) bool {
+	switch text[offset-1] {
+	case '\n':
+		return true
+	case '\r':
+		if offset < len(text) {
+			return text[offset] != '\n'
+		}
+		return next != '\n'
+	default:
+		return false
+	}
+}
+
+func crlf(last, first byte) int {
+	if last == '\r' && first == '\n' {
+		return 1
+	}
+	return 0
+}
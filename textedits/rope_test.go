@@ -0,0 +1,149 @@
+//
+// Copyright 2024 Cristian Maglie. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+//
+
+package textedits
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.bug.st/lsp"
+)
+
+func TestRope(t *testing.T) {
+	pieces := []string{"a", "bc", "è", "😀", "\n", "\r", "\r\n", "lorem ipsum dolor sit amet"}
+	randomText := func(r *rand.Rand, n int) string {
+		var s strings.Builder
+		for ; n > 0; n-- {
+			s.WriteString(pieces[r.Intn(len(pieces))])
+		}
+		return s.String()
+	}
+
+	r := rand.New(rand.NewSource(1))
+	text := randomText(r, 500)
+	rope := NewRope(text)
+	for i := 0; i < 1000; i++ {
+		start := r.Intn(len(text) + 1)
+		end := start + r.Intn(min(len(text)-start, 50)+1)
+		insert := randomText(r, r.Intn(5))
+		if r.Intn(50) == 0 {
+			insert = randomText(r, 200)
+		}
+
+		prev, prevText := rope, text
+		var err error
+		rope, err = rope.Replace(start, end, insert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text = text[:start] + insert + text[end:]
+		if rope.String() != text {
+			t.Fatalf("replace %d: expected %q, got %q", i, text, rope.String())
+		}
+		if prev.String() != prevText {
+			t.Fatalf("replace %d: previous snapshot changed", i)
+		}
+
+		// The string functions are the reference implementation
+		lineCount := strings.Count(text, "\n") + strings.Count(text, "\r") - strings.Count(text, "\r\n") + 1
+		if rope.LineCount() != lineCount {
+			t.Fatalf("replace %d: expected %d lines, got %d", i, lineCount, rope.LineCount())
+		}
+		for j := 0; j < 20; j++ {
+			line := r.Intn(lineCount)
+			exp, _ := GetLineOffset(text, line)
+			if act, err := rope.GetLineOffset(line); act != exp || err != nil {
+				t.Fatalf("replace %d: getLineOffset(%d) != %d, got %d, %v", i, line, exp, act, err)
+			}
+			offset := r.Intn(len(text) + 1)
+			expPos, _ := GetPosition(text, offset, lsp.PositionEncodingKindUTF16)
+			if act, err := rope.GetPosition(offset, lsp.PositionEncodingKindUTF16); act != expPos || err != nil {
+				t.Fatalf("replace %d: getPosition(%d) != %v, got %v, %v", i, offset, expPos, act, err)
+			}
+			pos := lsp.Position{Line: line, Character: r.Intn(10)}
+			expOffset, _ := GetOffset(text, pos, lsp.PositionEncodingKindUTF16)
+			if act, err := rope.GetOffset(pos, lsp.PositionEncodingKindUTF16); act != expOffset || err != nil {
+				t.Fatalf("replace %d: getOffset(%v) != %d, got %d, %v", i, pos, expOffset, act, err)
+			}
+		}
+	}
+	if rope.root.height > 20 {
+		t.Errorf("unbalanced rope: height %d with %d bytes", rope.root.height, rope.Len())
+	}
+
+	if _, err := rope.root.lineStart(rope.LineCount()); err != (OutOfRangeError{"Line", rope.LineCount() - 1, rope.LineCount()}) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+	if _, err := rope.Replace(-1, 0, ""); err != (OutOfRangeError{"Offset", rope.Len(), -1}) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+	if _, err := rope.Slice(1, rope.Len()+1); err != (OutOfRangeError{"Offset", rope.Len(), rope.Len() + 1}) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+}
+
+func TestRopeTextDocumentContentChanges(t *testing.T) {
+	rope := NewRope("foo\r\nbar\rbaz")
+	if rope.LineCount() != 3 {
+		t.Errorf("expected 3 lines, got %d", rope.LineCount())
+	}
+
+	res, err := rope.ApplyTextDocumentContentChanges([]lsp.TextDocumentContentChangeEvent{
+		{Range: &lsp.Range{Start: lsp.Position{Line: 0, Character: 3}, End: lsp.Position{Line: 1, Character: 0}}, Text: "\r"},
+		{Range: &lsp.Range{Start: lsp.Position{Line: 0, Character: 3}, End: lsp.Position{Line: 0, Character: 3}}, Text: "\n"},
+		{Range: &lsp.Range{Start: lsp.Position{Line: 3, Character: 1}, End: lsp.Position{Line: 3, Character: 2}}, Text: "😀"},
+	}, lsp.PositionEncodingKindUTF16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "foo\n\rbar\rb😀z"; res.String() != exp || res.LineCount() != 4 {
+		t.Errorf("expected %q with 4 lines, got %q with %d lines", exp, res.String(), res.LineCount())
+	}
+	if ext, err := res.ExtractRange(lsp.Range{Start: lsp.Position{Line: 3, Character: 1}, End: lsp.Position{Line: 3, Character: 2}}, lsp.PositionEncodingKindUTF32); ext != "😀" || err != nil {
+		t.Errorf("extractRange: got %q, %v", ext, err)
+	}
+
+	_, err = rope.ApplyTextDocumentContentChanges([]lsp.TextDocumentContentChangeEvent{
+		{Text: "x"},
+		{Range: &lsp.Range{Start: lsp.Position{Line: 2}, End: lsp.Position{Line: 2}}, Text: "y"},
+	}, lsp.PositionEncodingKindUTF16)
+	if err != (OutOfRangeError{"Line", 0, 2}) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+	if rope.String() != "foo\r\nbar\rbaz" {
+		t.Errorf("snapshot changed: %q", rope.String())
+	}
+}
+
+func TestRopeConcurrentSnapshots(t *testing.T) {
+	rope := NewRope(strings.Repeat("line\n", 1000))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		snapshot := rope
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if offset, err := snapshot.GetOffset(lsp.Position{Line: 500, Character: 2}, ""); offset != 2502 || err != nil {
+					t.Errorf("getOffset: got %d, %v", offset, err)
+					return
+				}
+			}
+		}()
+		var err error
+		rope, err = rope.Replace(0, 0, "edit\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+	if rope.LineCount() != 1009 {
+		t.Errorf("expected 1009 lines, got %d", rope.LineCount())
+	}
+}
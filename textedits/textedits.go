@@ -8,83 +8,108 @@ package textedits
 
 import (
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"go.bug.st/lsp"
 )
 
-// ApplyLSPTextDocumentContentChangeEvent applies the LSP change in the given text
-func ApplyLSPTextDocumentContentChangeEvent(doc lsp.TextDocumentItem, changes *lsp.DidChangeTextDocumentParams) (lsp.TextDocumentItem, error) {
-	if changes.TextDocument.URI != doc.URI {
-		return lsp.TextDocumentItem{}, fmt.Errorf("expected changes for %s but got changes for: %s", doc.URI, changes.TextDocument.URI)
+// ApplyLSPTextDocumentContentChangeEvent applies the LSP change in the given text.
+// The positions of the changes are interpreted with the given encoding, the
+// version of the document is set to the one of the change.
+// The whole text is copied on each call, use a Document to apply the changes
+// incrementally.
+func ApplyLSPTextDocumentContentChangeEvent(doc lsp.TextDocumentItem, changes *lsp.DidChangeTextDocumentParams, encoding lsp.PositionEncodingKind) (lsp.TextDocumentItem, error) {
+	d := NewDocument(doc, encoding)
+	if err := d.ApplyLSPTextDocumentContentChangeEvent(changes); err != nil {
+		return lsp.TextDocumentItem{}, err
 	}
-
-	for _, change := range changes.ContentChanges {
-		if change.Range == nil {
-			doc.Text = change.Text
-		} else if t, err := ApplyTextChange(doc.Text, *change.Range, change.Text); err == nil {
-			doc.Text = t
-		} else {
-			return lsp.TextDocumentItem{}, err
-		}
-	}
-	doc.Version++
-	return doc, nil
+	return d.TextDocumentItem(), nil
 }
 
-// ApplyTextChange replaces startingText substring specified by replaceRange with insertText
-func ApplyTextChange(startingText string, replaceRange lsp.Range, insertText string) (res string, err error) {
-	start, err := GetOffset(startingText, replaceRange.Start)
+// ApplyTextChange replaces startingText substring specified by replaceRange with insertText.
+// The range is interpreted with the given encoding.
+func ApplyTextChange(startingText string, replaceRange lsp.Range, insertText string, encoding lsp.PositionEncodingKind) (res string, err error) {
+	start, end, err := rangeOffsets(startingText, replaceRange, encoding)
 	if err != nil {
 		return "", err
 	}
-	end, err := GetOffset(startingText, replaceRange.End)
-	if err != nil {
-		return "", err
-	}
-
 	return startingText[:start] + insertText + startingText[end:], nil
 }
 
-// GetOffset computes the offset in the text expressed by the lsp.Position.
+// GetOffset computes the byte offset in the text expressed by the lsp.Position,
+// where the Character of the position counts the code units of the given
+// encoding (an empty encoding is UTF-16, the LSP default). If the position
+// points inside a character, the offset of the character is returned.
 // Returns OutOfRangeError if the position is out of range.
-func GetOffset(text string, pos lsp.Position) (int, error) {
+func GetOffset(text string, pos lsp.Position, encoding lsp.PositionEncodingKind) (int, error) {
 	// Find line
 	lineOffset, err := GetLineOffset(text, pos.Line)
 	if err != nil {
 		return -1, err
 	}
-	character := pos.Character
-	if character == 0 {
-		return lineOffset, nil
+	line := text[lineOffset:]
+	if end, _ := nextLineBreak(line); end != -1 {
+		line = line[:end]
 	}
 
-	// Find the character and return its offset within the text
-	var count = len(text[lineOffset:])
-	for offset, c := range text[lineOffset:] {
-		if character == offset {
-			// We've found the character
-			return lineOffset + offset, nil
-		}
-		if c == '\n' {
-			// We've reached the end of line. LSP spec says we should default back to the line length.
-			// See https://microsoft.github.io/language-server-protocol/specifications/specification-3-14/#position
-			if character > offset {
-				return lineOffset + offset, nil
+	offset, err := characterOffset(line, pos.Character, encoding)
+	if err != nil {
+		return -1, err
+	}
+	return lineOffset + offset, nil
+}
+
+// GetPosition computes the lsp.Position of the byte offset in the text, the
+// Character of the position counts the code units of the given encoding (an
+// empty encoding is UTF-16, the LSP default).
+// Returns OutOfRangeError if the offset is out of range.
+func GetPosition(text string, offset int, encoding lsp.PositionEncodingKind) (lsp.Position, error) {
+	if offset < 0 || offset > len(text) {
+		return lsp.Position{}, OutOfRangeError{"Offset", len(text), offset}
+	}
+	line, lineOffset := 0, 0
+	for {
+		end, size := nextLineBreak(text[lineOffset:])
+		if end == -1 || offset < lineOffset+end+size {
+			if end != -1 && offset > lineOffset+end {
+				// The offset is between '\r' and '\n', move it to the end of the line
+				offset = lineOffset + end
 			}
-			count = offset
 			break
 		}
+		lineOffset += end + size
+		line++
 	}
-	if character > 0 {
-		// We've reached the end of the last line. Default to the text length (see above).
-		return len(text), nil
+	return lsp.Position{Line: line, Character: encodedLen(text[lineOffset:offset], encoding)}, nil
+}
+
+// ConvertPosition converts the position in the text from an encoding to
+// another. Positions beyond the end of a line are moved to the end of the
+// line.
+func ConvertPosition(text string, pos lsp.Position, from, to lsp.PositionEncodingKind) (lsp.Position, error) {
+	offset, err := GetOffset(text, pos, from)
+	if err != nil {
+		return lsp.Position{}, err
 	}
+	return GetPosition(text, offset, to)
+}
 
-	// We haven't found the character in the text (character index was negative)
-	return -1, OutOfRangeError{"Character", count, character}
+// ConvertRange converts the range in the text from an encoding to another.
+func ConvertRange(text string, textRange lsp.Range, from, to lsp.PositionEncodingKind) (lsp.Range, error) {
+	start, err := ConvertPosition(text, textRange.Start, from, to)
+	if err != nil {
+		return lsp.Range{}, err
+	}
+	end, err := ConvertPosition(text, textRange.End, from, to)
+	if err != nil {
+		return lsp.Range{}, err
+	}
+	return lsp.Range{Start: start, End: end}, nil
 }
 
 // GetLineOffset finds the offset/position of the beginning of a line within the text.
+// Lines may be terminated by "\n", "\r\n" or "\r".
 // For example:
 //    text := "foo\nfoobar\nbaz"
 //    GetLineOffset(text, 0) == 0
@@ -96,13 +121,16 @@ func GetLineOffset(text string, line int) (int, error) {
 	}
 
 	// Find the line and return its offset within the text
-	var count int
-	for offset, c := range text {
-		if c == '\n' {
-			count++
-			if count == line {
-				return offset + 1, nil
-			}
+	var count, offset int
+	for {
+		end, size := nextLineBreak(text[offset:])
+		if end == -1 {
+			break
+		}
+		offset += end + size
+		count++
+		if count == line {
+			return offset, nil
 		}
 	}
 
@@ -110,17 +138,110 @@ func GetLineOffset(text string, line int) (int, error) {
 	return -1, OutOfRangeError{"Line", count, line}
 }
 
-// ExtractRange extract a piece of text from a text document given the range
-func ExtractRange(text string, textRange lsp.Range) (string, error) {
-	start, err := GetOffset(text, textRange.Start)
+// ExtractRange extract a piece of text from a text document given the range.
+// The range is interpreted with the given encoding.
+func ExtractRange(text string, textRange lsp.Range, encoding lsp.PositionEncodingKind) (string, error) {
+	start, end, err := rangeOffsets(text, textRange, encoding)
 	if err != nil {
 		return "", err
 	}
-	end, err := GetOffset(text, textRange.End)
+	return text[start:end], nil
+}
+
+// rangeOffsets returns the offsets of the start and the end of the range in
+// the text.
+func rangeOffsets(text string, textRange lsp.Range, encoding lsp.PositionEncodingKind) (int, int, error) {
+	start, err := GetOffset(text, textRange.Start, encoding)
 	if err != nil {
-		return "", err
+		return -1, -1, err
 	}
-	return text[start:end], nil
+	end, err := GetOffset(text, textRange.End, encoding)
+	if err != nil {
+		return -1, -1, err
+	}
+	if end < start {
+		return -1, -1, fmt.Errorf("invalid range %s: end before start", textRange)
+	}
+	return start, end, nil
+}
+
+// characterOffset returns the byte offset of the character in the line (not
+// including the line terminator), the character counts the code units of the
+// encoding. Characters past the end of the line are moved to the end of the
+// line.
+func characterOffset(line string, character int, encoding lsp.PositionEncodingKind) (int, error) {
+	if character < 0 {
+		// The character index is negative
+		return -1, OutOfRangeError{"Character", encodedLen(line, encoding), character}
+	}
+
+	// Find the character and return its offset within the line. If the end
+	// of the line is reached, the LSP spec says we should default back to the
+	// line length.
+	// See https://microsoft.github.io/language-server-protocol/specifications/specification-3-14/#position
+	units := 0
+	for offset := 0; offset < len(line); {
+		_, size := utf8.DecodeRuneInString(line[offset:])
+		units += runeUnits(line[offset:offset+size], encoding)
+		if units > character {
+			return offset, nil
+		}
+		offset += size
+	}
+	return len(line), nil
+}
+
+// encodedLen returns the length of the text in code units of the encoding.
+func encodedLen(text string, encoding lsp.PositionEncodingKind) int {
+	switch encoding {
+	case lsp.PositionEncodingKindUTF8:
+		return len(text)
+	case lsp.PositionEncodingKindUTF32:
+		return utf8.RuneCountInString(text)
+	default:
+		units := 0
+		for _, r := range text {
+			units += utf16RuneLen(r)
+		}
+		return units
+	}
+}
+
+// runeUnits returns the number of code units of the encoding used by the
+// (possibly invalid) UTF-8 encoded rune r.
+func runeUnits(r string, encoding lsp.PositionEncodingKind) int {
+	switch encoding {
+	case lsp.PositionEncodingKindUTF8:
+		return len(r)
+	case lsp.PositionEncodingKindUTF32:
+		return 1
+	default:
+		c, _ := utf8.DecodeRuneInString(r)
+		return utf16RuneLen(c)
+	}
+}
+
+// utf16RuneLen returns the number of UTF-16 code units needed to encode r,
+// characters outside the Basic Multilingual Plane use a surrogate pair.
+func utf16RuneLen(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// nextLineBreak returns the offset and the size of the first line terminator
+// in the text, or -1 if the text has no line terminators. As per LSP spec the
+// terminators are "\n", "\r\n" and "\r".
+func nextLineBreak(text string) (int, int) {
+	end := strings.IndexAny(text, "\r\n")
+	if end == -1 {
+		return -1, 0
+	}
+	if strings.HasPrefix(text[end:], "\r\n") {
+		return end, 2
+	}
+	return end, 1
 }
 
 // OutOfRangeError returned if one attempts to access text out of its range
@@ -101,7 +101,7 @@ func TestApplyTextChange(t *testing.T) {
 		expectation := strings.ReplaceAll(test.Expectation, "\n", "\\n")
 
 		t.Logf("applyTextChange(\"%s\", %v, \"%s\") == \"%s\"", initial, test.Range, insertion, expectation)
-		act, err := ApplyTextChange(test.InitialText, test.Range, test.Insertion)
+		act, err := ApplyTextChange(test.InitialText, test.Range, test.Insertion, lsp.PositionEncodingKindUTF8)
 		if act != test.Expectation {
 			t.Errorf("applyTextChange(\"%s\", %v, \"%s\") != \"%s\", got \"%s\"", initial, test.Range, insertion, expectation, strings.ReplaceAll(act, "\n", "\\n"))
 		}
@@ -134,7 +134,7 @@ func TestGetOffset(t *testing.T) {
 		st := strings.Replace(test.Text, "\n", "\\n", -1)
 
 		t.Logf("getOffset(\"%s\", {Line: %d, Character: %d}) == %d", st, test.Line, test.Char, test.Exp)
-		act, err := GetOffset(test.Text, lsp.Position{Line: test.Line, Character: test.Char})
+		act, err := GetOffset(test.Text, lsp.Position{Line: test.Line, Character: test.Char}, lsp.PositionEncodingKindUTF8)
 		if act != test.Exp {
 			t.Errorf("getOffset(\"%s\", {Line: %d, Character: %d}) != %d, got %d instead", st, test.Line, test.Char, test.Exp, act)
 		}
@@ -173,3 +173,133 @@ func TestGetLineOffset(t *testing.T) {
 		}
 	}
 }
+
+func TestGetOffsetWithEncodings(t *testing.T) {
+	// "è" is 2 bytes in UTF-8, "😀" is 4 bytes in UTF-8 and 2 code units in UTF-16
+	text := "a\nèa😀b\nc"
+	tests := []struct {
+		Encoding lsp.PositionEncodingKind
+		Char     int
+		Exp      int
+	}{
+		{lsp.PositionEncodingKindUTF8, 2, 4},
+		{lsp.PositionEncodingKindUTF8, 3, 5},
+		{lsp.PositionEncodingKindUTF8, 7, 9},
+		{lsp.PositionEncodingKindUTF8, 5, 5}, // inside the emoji
+		{lsp.PositionEncodingKindUTF16, 1, 4},
+		{lsp.PositionEncodingKindUTF16, 2, 5},
+		{lsp.PositionEncodingKindUTF16, 3, 5}, // inside the surrogate pair
+		{lsp.PositionEncodingKindUTF16, 4, 9},
+		{lsp.PositionEncodingKindUTF16, 5, 10},
+		{"", 4, 9},
+		{lsp.PositionEncodingKindUTF32, 3, 9},
+		{lsp.PositionEncodingKindUTF32, 4, 10},
+		{lsp.PositionEncodingKindUTF32, 10, 10},
+	}
+	for _, test := range tests {
+		act, err := GetOffset(text, lsp.Position{Line: 1, Character: test.Char}, test.Encoding)
+		if err != nil {
+			t.Errorf("getOffset(%d, %s) error: %s", test.Char, test.Encoding, err)
+		}
+		if act != test.Exp {
+			t.Errorf("getOffset(%d, %s) != %d, got %d instead", test.Char, test.Encoding, test.Exp, act)
+		}
+	}
+
+	if _, err := GetOffset(text, lsp.Position{Line: 1, Character: -1}, lsp.PositionEncodingKindUTF16); err != (OutOfRangeError{"Character", 5, -1}) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+
+	res, err := ApplyTextChange(text, lsp.Range{Start: lsp.Position{Line: 1, Character: 2}, End: lsp.Position{Line: 1, Character: 4}}, "-", lsp.PositionEncodingKindUTF16)
+	if err != nil || res != "a\nèa-b\nc" {
+		t.Errorf("applyTextChange: got \"%s\", %v", res, err)
+	}
+	ext, err := ExtractRange(text, lsp.Range{Start: lsp.Position{Line: 1, Character: 0}, End: lsp.Position{Line: 1, Character: 3}}, lsp.PositionEncodingKindUTF32)
+	if err != nil || ext != "èa😀" {
+		t.Errorf("extractRange: got \"%s\", %v", ext, err)
+	}
+}
+
+func TestConvertPosition(t *testing.T) {
+	text := "a\nèa😀b\nc"
+	pos, err := GetPosition(text, 9, lsp.PositionEncodingKindUTF16)
+	if err != nil || pos != (lsp.Position{Line: 1, Character: 4}) {
+		t.Errorf("getPosition: got %v, %v", pos, err)
+	}
+	pos, err = GetPosition(text, len(text), lsp.PositionEncodingKindUTF8)
+	if err != nil || pos != (lsp.Position{Line: 2, Character: 1}) {
+		t.Errorf("getPosition: got %v, %v", pos, err)
+	}
+	if _, err := GetPosition(text, 20, lsp.PositionEncodingKindUTF8); err != (OutOfRangeError{"Offset", 12, 20}) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+
+	pos, err = ConvertPosition(text, lsp.Position{Line: 1, Character: 9}, lsp.PositionEncodingKindUTF8, lsp.PositionEncodingKindUTF16)
+	if err != nil || pos != (lsp.Position{Line: 1, Character: 5}) {
+		t.Errorf("convertPosition: got %v, %v", pos, err)
+	}
+
+	rng, err := ConvertRange(text,
+		lsp.Range{Start: lsp.Position{Line: 1, Character: 2}, End: lsp.Position{Line: 1, Character: 4}},
+		lsp.PositionEncodingKindUTF16, lsp.PositionEncodingKindUTF32)
+	exp := lsp.Range{Start: lsp.Position{Line: 1, Character: 2}, End: lsp.Position{Line: 1, Character: 3}}
+	if err != nil || rng != exp {
+		t.Errorf("convertRange: got %v, %v", rng, err)
+	}
+	if _, err := ConvertRange(text, lsp.Range{End: lsp.Position{Line: 5}}, lsp.PositionEncodingKindUTF16, lsp.PositionEncodingKindUTF8); err != (OutOfRangeError{"Line", 2, 5}) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+}
+
+func TestLineTerminators(t *testing.T) {
+	// Lines: "a", "bc", "", "d", "e"
+	text := "a\r\nbc\r\rd\ne"
+	lineOffsets := []int{0, 3, 6, 7, 9}
+	for line, exp := range lineOffsets {
+		if act, err := GetLineOffset(text, line); act != exp || err != nil {
+			t.Errorf("getLineOffset(%d) != %d, got %d, %v instead", line, exp, act, err)
+		}
+	}
+	if _, err := GetLineOffset(text, 5); err != (OutOfRangeError{"Line", 4, 5}) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+	if _, err := GetLineOffset("a\rb", 2); err != (OutOfRangeError{"Line", 1, 2}) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+
+	// Characters past the end of the line stop before the line terminator
+	if act, err := GetOffset(text, lsp.Position{Line: 0, Character: 5}, lsp.PositionEncodingKindUTF16); act != 1 || err != nil {
+		t.Errorf("getOffset past end of line: got %d, %v", act, err)
+	}
+	if act, err := GetOffset(text, lsp.Position{Line: 1, Character: 2}, lsp.PositionEncodingKindUTF16); act != 5 || err != nil {
+		t.Errorf("getOffset at end of line: got %d, %v", act, err)
+	}
+
+	// Round trip every offset in the text, the offset between '\r' and '\n'
+	// is moved to the end of its line
+	for offset := 0; offset <= len(text); offset++ {
+		pos, err := GetPosition(text, offset, lsp.PositionEncodingKindUTF16)
+		if err != nil {
+			t.Fatalf("getPosition(%d): %s", offset, err)
+		}
+		exp := offset
+		if offset == 2 {
+			exp = 1
+			if pos != (lsp.Position{Line: 0, Character: 1}) {
+				t.Errorf("getPosition(%d) between '\\r' and '\\n': got %v", offset, pos)
+			}
+		}
+		if act, err := GetOffset(text, pos, lsp.PositionEncodingKindUTF16); act != exp || err != nil {
+			t.Errorf("getOffset(getPosition(%d) = %v) != %d, got %d, %v instead", offset, pos, exp, act, err)
+		}
+	}
+
+	res, err := ApplyTextChange(text, lsp.Range{Start: lsp.Position{Line: 1, Character: 1}, End: lsp.Position{Line: 3, Character: 0}}, "x", lsp.PositionEncodingKindUTF16)
+	if exp := "a\r\nbxd\ne"; res != exp || err != nil {
+		t.Errorf("applyTextChange: expected %q, got %q, %v", exp, res, err)
+	}
+	ext, err := ExtractRange(text, lsp.Range{Start: lsp.Position{Line: 0, Character: 0}, End: lsp.Position{Line: 1, Character: 1}}, lsp.PositionEncodingKindUTF8)
+	if exp := "a\r\nb"; ext != exp || err != nil {
+		t.Errorf("extractRange: expected %q, got %q, %v", exp, ext, err)
+	}
+}